@@ -1,13 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"blog-service/internal/config"
 	"blog-service/internal/db"
+	"blog-service/internal/mailer"
+	"blog-service/internal/oauth"
+	"blog-service/internal/ratelimit"
+	"blog-service/internal/repositories"
 	"blog-service/internal/router"
+	"blog-service/internal/services"
+	"blog-service/internal/storage"
+	"blog-service/internal/theme"
+	jwtutil "blog-service/internal/utils/jwt"
+	"blog-service/internal/utils/markdown"
+	"blog-service/internal/viewcount"
+	"blog-service/internal/worker"
 
 	"gorm.io/gorm"
 )
@@ -15,18 +34,59 @@ import (
 func main() {
 	cfg := config.Load()
 
-	if cfg.UploadDir != "" {
-		if err := os.MkdirAll(filepath.Clean(cfg.UploadDir), 0o755); err != nil {
-			log.Fatalf("create upload dir failed: %v", err)
+	// 收到退出信号时通知后台任务停止
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store, err := newStorage(cfg)
+	if err != nil {
+		log.Fatalf("init storage failed: %v", err)
+	}
+	// Markdown 中的媒体引用按存储后端改写为公开地址
+	markdown.SetMediaURLResolver(store.URL)
+
+	jm, err := newJWTManager(cfg)
+	if err != nil {
+		log.Fatalf("init jwt keys failed: %v", err)
+	}
+
+	oauthProviders, err := newOAuthProviders(cfg)
+	if err != nil {
+		log.Fatalf("init oauth providers failed: %v", err)
+	}
+
+	var site *theme.Theme
+	if cfg.ThemeEnabled {
+		site, err = theme.New(cfg.ThemeDir)
+		if err != nil {
+			log.Fatalf("load theme failed: %v", err)
+		}
+	}
+
+	var robots []byte
+	if cfg.RobotsFile != "" {
+		robots, err = os.ReadFile(cfg.RobotsFile)
+		if err != nil {
+			log.Fatalf("read robots file failed: %v", err)
 		}
 	}
 
 	var (
 		gdb    *gorm.DB
 		pingDB func() error
+		views  *viewcount.Counter
+
+		emailVerifySecret []byte
+		oauthStateSecret  []byte
+		sitemaps          *services.SitemapService
 	)
 
 	if cfg.MySQLDSN != "" {
+		emailVerifySecret = signingSecret("EMAIL_VERIFY_SECRET", cfg.EmailVerifySecret, cfg)
+		if len(oauthProviders) > 0 {
+			oauthStateSecret = signingSecret("OAUTH_STATE_SECRET", cfg.OAuthStateSecret, cfg)
+		}
+
 		d, err := db.Open(cfg.MySQLDSN)
 		if err != nil {
 			log.Fatalf("mysql connect failed: %v", err)
@@ -34,18 +94,227 @@ func main() {
 		db.EnsureSchema(d.Gorm)
 		gdb = d.Gorm
 		pingDB = d.SQL.Ping
+
+		postRepo := repositories.NewPostRepo(gdb)
+		// sitemap 缓存由 HTTP 接口与定时发布共用，文章上线时清空
+		sitemaps = &services.SitemapService{
+			Posts:      postRepo,
+			Tags:       repositories.NewTagRepo(gdb),
+			Categories: repositories.NewCategoryRepo(gdb),
+			BaseURL:    cfg.BaseURL,
+			TTL:        cfg.SitemapCacheTTL,
+		}
+
+		publisher := &worker.ScheduledPublisher{
+			Posts:    &services.PostService{Posts: postRepo, Sitemap: sitemaps},
+			Interval: cfg.PublishInterval,
+		}
+		go publisher.Run(ctx)
+
+		cleaner := &worker.TokenCleaner{
+			Tokens:        repositories.NewRefreshTokenRepo(gdb),
+			MFA:           repositories.NewMFARepo(gdb),
+			LoginFailures: repositories.NewLoginFailureRepo(gdb),
+		}
+		go cleaner.Run(ctx)
+
+		views = viewcount.New(postRepo.AddViewCounts, cfg.ViewFlushInterval, cfg.ViewDedupWindow)
+		go views.Run(ctx)
 	} else {
 		log.Println("MYSQL_DSN empty: running without database")
 	}
 
 	r := router.New(router.Deps{
-		DB:        gdb,
-		PingDB:    pingDB,
-		JWTSecret: cfg.JWTSecret,
+		DB:              gdb,
+		PingDB:          pingDB,
+		JWT:             jm,
+		RefreshTokenTTL: cfg.RefreshTokenTTL,
+		Mailer:          mailer.New(cfg.Mailer, cfg.MailDir),
+
+		Storage:        store,
+		UploadMaxBytes: cfg.UploadMaxBytes,
+
+		CommentModeration: cfg.CommentModeration,
+
+		BaseURL:              cfg.BaseURL,
+		EmailVerifySecret:    emailVerifySecret,
+		RequireVerifiedEmail: cfg.RequireVerifiedEmail,
+
+		SiteTitle:       cfg.SiteTitle,
+		SiteDescription: cfg.SiteDescription,
+		SiteLanguage:    cfg.SiteLanguage,
+
+		Theme: site,
+
+		RobotsTxt:      robots,
+		RobotsDisallow: splitList(cfg.RobotsDisallow),
+		Sitemap:        sitemaps,
+
+		Views: views,
+
+		OAuthProviders:   oauthProviders,
+		OAuthStateSecret: oauthStateSecret,
+
+		RequireAdminMFA: cfg.MFARequireAdmin,
+		MFAIssuer:       cfg.MFAIssuer,
+
+		RateLimit:      newRateLimitStore(cfg),
+		TrustedProxies: splitList(cfg.TrustedProxies),
 	})
 
-	log.Printf("server listening on %s", cfg.Addr)
-	if err := r.Run(cfg.Addr); err != nil {
-		log.Fatalf("server run failed: %v", err)
+	srv := &http.Server{Addr: cfg.Addr, Handler: r}
+	go func() {
+		log.Printf("server listening on %s", cfg.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("server run failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("server shutdown failed: %v", err)
+	}
+	// 请求处理完之后写回剩余的浏览量
+	if views != nil {
+		if err := views.FlushNow(); err != nil {
+			log.Printf("flush view counts failed: %v", err)
+		}
+	}
+}
+
+// 独立配置的签名密钥；未配置时沿用 JWT_SECRET，但它还是公开的默认值时拒绝启动
+// （非对称 JWT 部署通常不会设置 JWT_SECRET，沿用默认值等于任何人都能伪造签名）
+// 示例配置里的占位值（以 change-me 结尾）同样公开，也拒绝启动
+func signingSecret(name, v string, cfg config.Config) []byte {
+	if strings.HasSuffix(v, "change-me") {
+		log.Fatalf("%s is set to a placeholder value; use a random secret", name)
+	}
+	if v != "" {
+		return []byte(v)
+	}
+	if cfg.JWTSecret == config.DefaultJWTSecret {
+		log.Fatalf("%s is required when JWT_SECRET is left at its default value", name)
+	}
+	return []byte(cfg.JWTSecret)
+}
+
+// 未配置私钥时沿用 HS256 + JWT_SECRET
+func newJWTManager(cfg config.Config) (jwtutil.Manager, error) {
+	m := jwtutil.Manager{
+		Issuer: "blog-service",
+		TTL:    cfg.AccessTokenTTL,
+	}
+	if cfg.JWTPrivateKeyFile == "" {
+		m.Secret = []byte(cfg.JWTSecret)
+		return m, nil
+	}
+
+	k, err := jwtutil.LoadPrivateKeyFile(cfg.JWTPrivateKeyFile)
+	if err != nil {
+		return m, err
+	}
+	m.SigningKey = k
+	for _, path := range splitList(cfg.JWTPublicKeyFiles) {
+		pk, err := jwtutil.LoadPublicKeyFile(path)
+		if err != nil {
+			return m, err
+		}
+		m.VerifyKeys = append(m.VerifyKeys, pk)
+	}
+	if cfg.JWTAcceptHS256 {
+		m.Secret = []byte(cfg.JWTSecret)
+	}
+	log.Printf("jwt: signing with %s (kid %s), %d extra verification key(s)", k.Method.Alg(), k.ID, len(m.VerifyKeys))
+	return m, nil
+}
+
+// 只启用配置了 client id 的提供方；回调地址固定为 BASE_URL/api/v1/auth/oauth/{name}/callback
+func newOAuthProviders(cfg config.Config) ([]oauth.Provider, error) {
+	callback := func(name string) string {
+		return strings.TrimRight(cfg.BaseURL, "/") + "/api/v1/auth/oauth/" + name + "/callback"
+	}
+
+	var out []oauth.Provider
+	if cfg.GitHubClientID != "" {
+		p, err := oauth.NewGitHub(oauth.GitHubConfig{
+			ClientID:     cfg.GitHubClientID,
+			ClientSecret: cfg.GitHubClientSecret,
+			RedirectURL:  callback("github"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	if cfg.OIDCClientID != "" {
+		p, err := oauth.NewOIDC(oauth.OIDCConfig{
+			Name:         cfg.OIDCName,
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  callback(cfg.OIDCName),
+			Scopes:       strings.Fields(cfg.OIDCScopes),
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// off 时返回 nil，中间件直接放行
+func newRateLimitStore(cfg config.Config) ratelimit.Store {
+	switch cfg.RateLimitStore {
+	case "off":
+		log.Println("rate limiting disabled")
+		return nil
+	case "redis":
+		return ratelimit.NewRedis(ratelimit.RedisConfig{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       int(cfg.RedisDB),
+		})
+	case "", "memory":
+		return ratelimit.NewMemory()
+	default:
+		log.Fatalf("unknown RATE_LIMIT_STORE %q", cfg.RateLimitStore)
+		return nil
+	}
+}
+
+// 逗号分隔的配置项，忽略空白项
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func newStorage(cfg config.Config) (storage.Storage, error) {
+	switch cfg.StorageBackend {
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PublicURL: cfg.S3PublicURL,
+			PathStyle: cfg.S3PathStyle,
+		})
+	case "", "local":
+		if err := os.MkdirAll(filepath.Clean(cfg.UploadDir), 0o755); err != nil {
+			return nil, fmt.Errorf("create upload dir failed: %w", err)
+		}
+		return storage.NewLocal(cfg.UploadDir, "/uploads"), nil
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q", cfg.StorageBackend)
 	}
 }
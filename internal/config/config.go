@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,21 +15,144 @@ type Config struct {
 	MySQLDSN string
 
 	JWTSecret string
+	// 配置私钥后改用非对称签名（RSA→RS256，Ed25519→EdDSA），公钥通过 JWKS 发布
+	JWTPrivateKeyFile string
+	// 轮换期内仍需验签的旧公钥文件，逗号分隔
+	JWTPublicKeyFiles string
+	// 非对称模式下是否继续接受 JWT_SECRET 签发的 HS256 token（迁移过渡用）
+	JWTAcceptHS256 bool
+	// access token 短期有效，靠 refresh token 续期
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
 	UploadDir string
+	// 单个上传文件大小上限（字节）
+	UploadMaxBytes int64
+
+	// 上传存储后端：local（默认，存 UploadDir）/ s3（S3 兼容对象存储）
+	StorageBackend string
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3PublicURL    string
+	S3PathStyle    bool
 
 	CommentModeration bool
+
+	// 定时发布扫描间隔
+	PublishInterval time.Duration
+	// 浏览量批量写回间隔与同一访客去重窗口
+	ViewFlushInterval time.Duration
+	ViewDedupWindow   time.Duration
+
+	// 发信方式：log（默认，打印日志）/ file（写入 MailDir）
+	Mailer  string
+	MailDir string
+
+	// 限流存储：memory（默认，单实例）/ redis（多实例共享）/ off
+	RateLimitStore string
+	RedisAddr      string
+	RedisPassword  string
+	RedisDB        int64
+	// 站点对外地址，用于生成邮件、订阅源中的绝对链接
+	BaseURL string
+	// 站点名称、简介与语言（订阅源）
+	SiteTitle       string
+	SiteDescription string
+	SiteLanguage    string
+	// 服务端渲染页面：开关与模板覆盖目录
+	ThemeEnabled bool
+	ThemeDir     string
+	// robots.txt：配置文件时原样输出，否则按禁止路径（逗号分隔）生成
+	RobotsFile     string
+	RobotsDisallow string
+	// sitemap 缓存有效期（文章变化时也会清空）
+	SitemapCacheTTL time.Duration
+	// 邮箱验证链接签名密钥，留空时使用 JWTSecret（JWTSecret 为默认值时必须配置）
+	EmailVerifySecret string
+	// 未验证邮箱的用户不能评论、点赞
+	RequireVerifiedEmail bool
+
+	// 第三方登录：GitHub 预设与通用 OIDC，未配置 client id 时不启用
+	GitHubClientID     string
+	GitHubClientSecret string
+	OIDCName           string
+	OIDCIssuer         string
+	OIDCClientID       string
+	OIDCClientSecret   string
+	OIDCScopes         string
+	// OAuth state cookie 签名密钥，留空时使用 JWTSecret（JWTSecret 为默认值时必须配置）
+	OAuthStateSecret string
+
+	// 管理员必须启用 TOTP 二次验证
+	MFARequireAdmin bool
+	// 验证器 App 中显示的服务名
+	MFAIssuer string
+
+	// 反向代理地址（逗号分隔），只信任来自这些地址的 X-Forwarded-For
+	TrustedProxies string
 }
 
+// JWT_SECRET 的开发默认值（公开的，不能用于任何签名）
+const DefaultJWTSecret = "dev-secret-change-me"
+
 func Load() Config {
 	// 本地开发优先加载 .env（没有也不报错）
 	_ = godotenv.Load()
 
 	return Config{
-		Addr:              getEnv("APP_ADDR", ":8080"),
-		MySQLDSN:          getEnv("MYSQL_DSN", ""),
-		JWTSecret:         getEnv("JWT_SECRET", "dev-secret-change-me"),
-		UploadDir:         getEnv("UPLOAD_DIR", "./uploads"),
-		CommentModeration: getEnvBool("COMMENT_MODERATION", false),
+		Addr:                 getEnv("APP_ADDR", ":8080"),
+		MySQLDSN:             getEnv("MYSQL_DSN", ""),
+		JWTSecret:            getEnv("JWT_SECRET", DefaultJWTSecret),
+		JWTPrivateKeyFile:    getEnv("JWT_PRIVATE_KEY_FILE", ""),
+		JWTPublicKeyFiles:    getEnv("JWT_PUBLIC_KEY_FILES", ""),
+		JWTAcceptHS256:       getEnvBool("JWT_ACCEPT_HS256", false),
+		AccessTokenTTL:       getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:      getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		UploadDir:            getEnv("UPLOAD_DIR", "./uploads"),
+		UploadMaxBytes:       getEnvInt64("UPLOAD_MAX_BYTES", 10<<20),
+		StorageBackend:       getEnv("STORAGE_BACKEND", "local"),
+		S3Endpoint:           getEnv("S3_ENDPOINT", ""),
+		S3Region:             getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:             getEnv("S3_BUCKET", ""),
+		S3AccessKey:          getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:          getEnv("S3_SECRET_KEY", ""),
+		S3PublicURL:          getEnv("S3_PUBLIC_URL", ""),
+		S3PathStyle:          getEnvBool("S3_PATH_STYLE", true),
+		CommentModeration:    getEnvBool("COMMENT_MODERATION", false),
+		PublishInterval:      getEnvDuration("PUBLISH_INTERVAL", 30*time.Second),
+		ViewFlushInterval:    getEnvDuration("VIEW_FLUSH_INTERVAL", 10*time.Second),
+		ViewDedupWindow:      getEnvDuration("VIEW_DEDUP_WINDOW", 30*time.Minute),
+		Mailer:               getEnv("MAILER", "log"),
+		MailDir:              getEnv("MAIL_DIR", "./mail"),
+		RateLimitStore:       getEnv("RATE_LIMIT_STORE", "memory"),
+		RedisAddr:            getEnv("REDIS_ADDR", "127.0.0.1:6379"),
+		RedisPassword:        getEnv("REDIS_PASSWORD", ""),
+		RedisDB:              getEnvInt64("REDIS_DB", 0),
+		TrustedProxies:       getEnv("TRUSTED_PROXIES", ""),
+		BaseURL:              getEnv("BASE_URL", "http://localhost:8080"),
+		SiteTitle:            getEnv("SITE_TITLE", "Blog"),
+		SiteDescription:      getEnv("SITE_DESCRIPTION", ""),
+		SiteLanguage:         getEnv("SITE_LANGUAGE", "zh-CN"),
+		ThemeEnabled:         getEnvBool("THEME_ENABLED", false),
+		ThemeDir:             getEnv("THEME_DIR", ""),
+		RobotsFile:           getEnv("ROBOTS_FILE", ""),
+		RobotsDisallow:       getEnv("ROBOTS_DISALLOW", "/api/"),
+		SitemapCacheTTL:      getEnvDuration("SITEMAP_CACHE_TTL", time.Hour),
+		EmailVerifySecret:    getEnv("EMAIL_VERIFY_SECRET", ""),
+		RequireVerifiedEmail: getEnvBool("REQUIRE_VERIFIED_EMAIL", false),
+		GitHubClientID:       getEnv("GITHUB_CLIENT_ID", ""),
+		GitHubClientSecret:   getEnv("GITHUB_CLIENT_SECRET", ""),
+		OIDCName:             getEnv("OIDC_NAME", "oidc"),
+		OIDCIssuer:           getEnv("OIDC_ISSUER", ""),
+		OIDCClientID:         getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:     getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCScopes:           getEnv("OIDC_SCOPES", "openid email profile"),
+		OAuthStateSecret:     getEnv("OAUTH_STATE_SECRET", ""),
+		MFARequireAdmin:      getEnvBool("MFA_REQUIRE_ADMIN", false),
+		MFAIssuer:            getEnv("MFA_ISSUER", "Blog Service"),
 	}
 }
 
@@ -50,3 +174,27 @@ func getEnvBool(key string, def bool) bool {
 	}
 	return b
 }
+
+func getEnvInt64(key string, def int64) int64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def
+	}
+	return d
+}
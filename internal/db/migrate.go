@@ -9,17 +9,35 @@ import (
 )
 
 func Migrate(gdb *gorm.DB) error {
+	// 邮箱验证是后加的：首次加列时把存量用户视为已验证，避免开启校验后老用户被拦
+	backfillEmailVerified := gdb.Migrator().HasTable(&models.User{}) &&
+		!gdb.Migrator().HasColumn(&models.User{}, "EmailVerifiedAt")
+
 	// 1) 基础表
 	if err := gdb.AutoMigrate(
 		&models.User{},
+		&models.Category{},
 		&models.Post{},
 		&models.Tag{},
 		&models.Comment{},
 		&models.PostLike{},
 		&models.PasswordResetToken{},
+		&models.Media{},
+		&models.PostRevision{},
+		&models.RefreshToken{},
+		&models.TokenRevocation{},
+		&models.MFARecoveryCode{},
+		&models.MFAChallenge{},
+		&models.UserIdentity{},
+		&models.LoginFailure{},
 	); err != nil {
 		return err
 	}
+	if backfillEmailVerified {
+		if err := gdb.Exec(`UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL`).Error; err != nil {
+			return err
+		}
+	}
 
 	// 2) post_likes 联合唯一
 	if err := gdb.Exec(`
@@ -38,6 +56,34 @@ func Migrate(gdb *gorm.DB) error {
 	`).Error; err != nil {
 	}
 
+	// 4) 枚举扩容：AutoMigrate 不会修改已存在 enum 列的取值，需显式 MODIFY（可重复执行）
+	if err := gdb.Exec(`
+		ALTER TABLE comments
+		MODIFY COLUMN status enum('pending','approved','rejected','spam') NOT NULL DEFAULT 'approved'
+	`).Error; err != nil {
+		return err
+	}
+	if err := gdb.Exec(`
+		ALTER TABLE posts
+		MODIFY COLUMN status enum('draft','published','scheduled') NOT NULL DEFAULT 'draft'
+	`).Error; err != nil {
+		return err
+	}
+	if err := gdb.Exec(`
+		ALTER TABLE users
+		MODIFY COLUMN role enum('admin','editor','author','moderator','user') NOT NULL DEFAULT 'user'
+	`).Error; err != nil {
+		return err
+	}
+
+	// 5) 定时发布扫描用的联合索引
+	if err := gdb.Exec(`
+		ALTER TABLE posts
+		ADD INDEX idx_posts_status_published_at (status, published_at)
+	`).Error; err != nil {
+		// 已存在时忽略，同上
+	}
+
 	return nil
 }
 
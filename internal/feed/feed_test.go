@@ -0,0 +1,141 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleFeed() Feed {
+	pub := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	return Feed{
+		Title:       "Blog",
+		Description: "notes",
+		SiteURL:     "https://blog.example.com/",
+		FeedURL:     "https://blog.example.com/feed.xml",
+		Updated:     pub.Add(time.Hour),
+		Items: []Item{{
+			ID:        TagURI("blog.example.com", pub, "post", 42),
+			Title:     "Hello <World>",
+			URL:       "https://blog.example.com/posts/hello",
+			HTML:      `<p>hi ]]> <img src="https://blog.example.com/uploads/a.png"></p>`,
+			Author:    "alice",
+			Tags:      []string{"go", "web"},
+			Published: pub,
+			Updated:   pub.Add(time.Hour),
+		}},
+	}
+}
+
+func TestTagURI(t *testing.T) {
+	got := TagURI("blog.example.com", time.Date(2024, 5, 1, 23, 0, 0, 0, time.FixedZone("x", -3600)), "post", 42)
+	if got != "tag:blog.example.com,2024-05-02:post:42" {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestAbsoluteURLs(t *testing.T) {
+	in := `<a href="/posts/x">x</a><img src='/uploads/a.png'><a href="//cdn.example.com/y">y</a><a href="https://o.example/">o</a>`
+	got := AbsoluteURLs(in, "https://blog.example.com/")
+	want := `<a href="https://blog.example.com/posts/x">x</a><img src='https://blog.example.com/uploads/a.png'><a href="//cdn.example.com/y">y</a><a href="https://o.example/">o</a>`
+	if got != want {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestRSS(t *testing.T) {
+	b, err := RSS(sampleFeed())
+	if err != nil {
+		t.Fatalf("rss err: %v", err)
+	}
+	var doc struct {
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				Title       string   `xml:"title"`
+				GUID        string   `xml:"guid"`
+				PubDate     string   `xml:"pubDate"`
+				Categories  []string `xml:"category"`
+				Description string   `xml:"description"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("invalid xml: %v\n%s", err, b)
+	}
+	if len(doc.Channel.Items) != 1 {
+		t.Fatalf("want 1 item, got %d", len(doc.Channel.Items))
+	}
+	it := doc.Channel.Items[0]
+	if it.Title != "Hello <World>" || it.GUID != "tag:blog.example.com,2024-05-01:post:42" {
+		t.Fatalf("unexpected item %+v", it)
+	}
+	if it.PubDate != "Wed, 01 May 2024 08:00:00 +0000" {
+		t.Fatalf("pubDate %s", it.PubDate)
+	}
+	// 正文里的 ]]> 不能截断 CDATA
+	if it.Description != sampleFeed().Items[0].HTML {
+		t.Fatalf("description mismatch: %s", it.Description)
+	}
+	if !strings.Contains(string(b), `<atom:link href="https://blog.example.com/feed.xml" rel="self"`) {
+		t.Fatalf("missing self link:\n%s", b)
+	}
+}
+
+func TestAtom(t *testing.T) {
+	b, err := Atom(sampleFeed())
+	if err != nil {
+		t.Fatalf("atom err: %v", err)
+	}
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Content struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+			Author struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("invalid xml: %v\n%s", err, b)
+	}
+	if doc.Updated != "2024-05-01T09:00:00Z" || len(doc.Entries) != 1 {
+		t.Fatalf("unexpected feed %+v", doc)
+	}
+	e := doc.Entries[0]
+	if e.Content.Type != "html" || e.Content.Value != sampleFeed().Items[0].HTML || e.Author.Name != "alice" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	b, err := JSON(sampleFeed())
+	if err != nil {
+		t.Fatalf("json err: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if doc["version"] != "https://jsonfeed.org/version/1.1" || doc["feed_url"] != "https://blog.example.com/feed.xml" {
+		t.Fatalf("unexpected feed %s", b)
+	}
+	items := doc["items"].([]any)
+	it := items[0].(map[string]any)
+	if it["content_html"] != sampleFeed().Items[0].HTML || it["date_published"] != "2024-05-01T08:00:00Z" {
+		t.Fatalf("unexpected item %v", it)
+	}
+
+	// 没有文章时 items 仍为数组
+	b, _ = JSON(Feed{Title: "empty"})
+	if !strings.Contains(string(b), `"items": []`) {
+		t.Fatalf("items should be empty array: %s", b)
+	}
+}
@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"blog-service/internal/middleware"
+	"blog-service/internal/models"
 	"blog-service/internal/repositories"
 	"blog-service/internal/services"
 
@@ -49,10 +55,11 @@ func (h AuthHandler) Register(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, gin.H{
 		"user": gin.H{
-			"id":       u.ID,
-			"email":    u.Email,
-			"username": u.Username,
-			"role":     u.Role,
+			"id":             u.ID,
+			"email":          u.Email,
+			"username":       u.Username,
+			"role":           u.Role,
+			"email_verified": u.EmailVerified(),
 		},
 	})
 }
@@ -73,25 +80,108 @@ func (h AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, u, err := h.Auth.Login(req.EmailOrUsername, req.Password)
+	tokens, u, err := h.Auth.Login(req.EmailOrUsername, req.Password, c.ClientIP())
 	if err != nil {
-		if err == services.ErrInvalidCredentials {
+		var locked *services.AccountLockedError
+		if errors.As(err, &locked) {
+			middleware.AbortTooManyRequests(c, time.Until(locked.Until).Seconds())
+			return
+		}
+		// 已启用二次验证：返回 mfa_token，客户端再调用 /auth/mfa/verify
+		var mfa *services.MFARequiredError
+		if errors.As(err, &mfa) {
+			c.JSON(http.StatusOK, gin.H{
+				"mfa_required": true,
+				"mfa_token":    mfa.Token,
+				"expires_in":   int(mfa.ExpiresIn.Seconds()),
+			})
+			return
+		}
+		switch err {
+		case services.ErrInvalidCredentials:
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_credentials"})
+		case services.ErrAccountDisabled, services.ErrPasswordResetReq:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		}
+		return
+	}
+
+	out := tokenPairDTO(tokens)
+	out["user"] = gin.H{
+		"id":       u.ID,
+		"email":    u.Email,
+		"username": u.Username,
+		"role":     u.Role,
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+type refreshReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=255"`
+}
+
+func (h AuthHandler) Refresh(c *gin.Context) {
+	var req refreshReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if err := h.V.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_error"})
+		return
+	}
+
+	tokens, err := h.Auth.RefreshTokens(req.RefreshToken)
+	if err != nil {
+		if err == services.ErrInvalidRefresh {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_refresh_token"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
 		return
 	}
+	c.JSON(http.StatusOK, tokenPairDTO(tokens))
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"access_token": token,
-		"user": gin.H{
-			"id":       u.ID,
-			"email":    u.Email,
-			"username": u.Username,
-			"role":     u.Role,
-		},
-	})
+type logoutReq struct {
+	RefreshToken string `json:"refresh_token" validate:"max=255"`
+}
+
+// 登出：吊销当前 access token 所在会话，以及 body 中 refresh token 所在会话；
+// access token 已过期时只凭 refresh token 也可以登出。body 可为空
+func (h AuthHandler) Logout(c *gin.Context) {
+	var req logoutReq
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if err := h.V.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_error"})
+		return
+	}
+
+	claims, _ := middleware.GetAuthClaims(c)
+	if claims == nil && strings.TrimSpace(req.RefreshToken) == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.Auth.Logout(claims, req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func tokenPairDTO(t *services.TokenPair) gin.H {
+	return gin.H{
+		"access_token":  t.AccessToken,
+		"refresh_token": t.RefreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(t.ExpiresIn.Seconds()),
+	}
 }
 
 func (h AuthHandler) Me(c *gin.Context) {
@@ -105,13 +195,129 @@ func (h AuthHandler) Me(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
+	mfaEnabled, codesLeft, err := h.Auth.MFAStatus(u)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"user": gin.H{
-			"id":       u.ID,
-			"email":    u.Email,
-			"username": u.Username,
-			"role":     u.Role,
+			"id":             u.ID,
+			"email":          u.Email,
+			"username":       u.Username,
+			"role":           u.Role,
+			"email_verified": u.EmailVerified(),
+		},
+		// required 为 true 且未启用时管理权限不生效，需先完成绑定
+		"mfa": gin.H{
+			"enabled":             mfaEnabled,
+			"required":            h.Auth.RequireAdminMFA && u.Role == models.RoleAdmin,
+			"recovery_codes_left": codesLeft,
 		},
 	})
 }
+
+type forgotPasswordReq struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+func (h AuthHandler) ForgotPassword(c *gin.Context) {
+	var req forgotPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if err := h.V.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_error"})
+		return
+	}
+
+	if err := h.Auth.ForgotPassword(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+
+	// 无论邮箱是否存在都返回同样结果
+	c.JSON(http.StatusAccepted, gin.H{"message": "if_account_exists_reset_email_sent"})
+}
+
+type resetPasswordReq struct {
+	Token    string `json:"token" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+func (h AuthHandler) ResetPassword(c *gin.Context) {
+	var req resetPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if err := h.V.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_error"})
+		return
+	}
+
+	if err := h.Auth.ResetPassword(req.Token, req.Password); err != nil {
+		if err == services.ErrInvalidResetToken {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_reset_token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GET /auth/email/verify?uid=&exp=&sig=：邮件中的验证链接
+func (h AuthHandler) VerifyEmail(c *gin.Context) {
+	uid, err := strconv.ParseUint(c.Query("uid"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_verification_link"})
+		return
+	}
+	exp, err := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_verification_link"})
+		return
+	}
+
+	u, err := h.Auth.VerifyEmail(uint(uid), exp, c.Query("sig"))
+	if err != nil {
+		if err == services.ErrInvalidVerifyLink {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_verification_link"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"email": u.Email, "email_verified": true})
+}
+
+// 重发验证邮件（需登录）
+func (h AuthHandler) ResendVerification(c *gin.Context) {
+	uid, ok := middleware.GetAuthUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.Auth.ResendVerification(uid); err != nil {
+		var tooSoon *services.VerifyTooSoonError
+		if errors.As(err, &tooSoon) {
+			middleware.AbortTooManyRequests(c, time.Until(tooSoon.Until).Seconds())
+			return
+		}
+		switch err {
+		case services.ErrEmailAlreadyVerified:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case services.ErrUserNotFound:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		}
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "verification_email_sent"})
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"blog-service/internal/models"
+	"blog-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type CategoryHandler struct {
+	Categories *services.CategoryService
+	V          *validator.Validate
+}
+
+// GET /api/v1/categories：分类树
+func (h CategoryHandler) Tree(c *gin.Context) {
+	nodes, err := h.Categories.Tree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": categoryTreeDTO(nodes)})
+}
+
+// GET /api/v1/categories/:slug/posts：包含子孙分类下的文章
+func (h CategoryHandler) Posts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.Query("page"))
+	size, _ := strconv.Atoi(c.Query("size"))
+
+	cat, items, total, err := h.Categories.ListPosts(c.Param("slug"), page, size)
+	if err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"category": categoryDTO(cat),
+		"total":    total,
+		"items":    postListDTO(items),
+	})
+}
+
+type createCategoryReq struct {
+	Name        string `json:"name" validate:"required,max=50"`
+	Slug        string `json:"slug" validate:"required,max=80"`
+	Description string `json:"description" validate:"max=255"`
+	ParentID    *uint  `json:"parent_id"`
+	SortOrder   int    `json:"sort_order"`
+}
+
+func (h CategoryHandler) Create(c *gin.Context) {
+	var req createCategoryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if err := h.V.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_error"})
+		return
+	}
+
+	cat, err := h.Categories.Create(services.CategoryInput{
+		Name:        &req.Name,
+		Slug:        &req.Slug,
+		Description: &req.Description,
+		ParentID:    req.ParentID,
+		SortOrder:   &req.SortOrder,
+	})
+	if err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, categoryDTO(cat))
+}
+
+type updateCategoryReq struct {
+	Name        *string `json:"name" validate:"omitempty,max=50"`
+	Slug        *string `json:"slug" validate:"omitempty,max=80"`
+	Description *string `json:"description" validate:"omitempty,max=255"`
+	ParentID    *uint   `json:"parent_id"` // 0 表示移到顶层
+	SortOrder   *int    `json:"sort_order"`
+}
+
+func (h CategoryHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+
+	var req updateCategoryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if err := h.V.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_error"})
+		return
+	}
+
+	cat, err := h.Categories.Update(uint(id), services.CategoryInput(req))
+	if err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, categoryDTO(cat))
+}
+
+func (h CategoryHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if err := h.Categories.Delete(uint(id)); err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeCategoryError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrCategoryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+	case services.ErrCategorySlugTaken, services.ErrCategoryHasChildren:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrCategoryNameRequired, services.ErrCategorySlugRequired, services.ErrInvalidParent:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+	}
+}
+
+// ---- DTO helpers ----
+
+func categoryDTO(cat *models.Category) gin.H {
+	return gin.H{
+		"id":          cat.ID,
+		"name":        cat.Name,
+		"slug":        cat.Slug,
+		"description": cat.Description,
+		"parent_id":   cat.ParentID,
+		"sort_order":  cat.SortOrder,
+	}
+}
+
+func categoryTreeDTO(nodes []*services.CategoryNode) []gin.H {
+	out := make([]gin.H, 0, len(nodes))
+	for _, n := range nodes {
+		item := categoryDTO(&n.Category)
+		item["post_count"] = n.PostCount
+		item["children"] = categoryTreeDTO(n.Children)
+		out = append(out, item)
+	}
+	return out
+}
+
+// 文章上的分类摘要；未分类时为 null
+func postCategoryDTO(cat *models.Category) any {
+	if cat == nil {
+		return nil
+	}
+	return gin.H{"id": cat.ID, "name": cat.Name, "slug": cat.Slug}
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blog-service/internal/middleware"
+	"blog-service/internal/models"
+	"blog-service/internal/repositories"
+	"blog-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type CommentHandler struct {
+	Comments *services.CommentService
+	V        *validator.Validate
+}
+
+func (h CommentHandler) List(c *gin.Context) {
+	nodes, err := h.Comments.ListTree(c.Param("slug"))
+	if err != nil {
+		writeCommentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": commentTreeDTO(nodes)})
+}
+
+type createCommentReq struct {
+	Content  string `json:"content" validate:"required,max=5000"`
+	ParentID *uint  `json:"parent_id" validate:"omitempty,min=1"`
+}
+
+func (h CommentHandler) Create(c *gin.Context) {
+	uid, _ := middleware.GetAuthUserID(c)
+	canModerate := middleware.HasPermission(c, models.PermCommentsModerate)
+
+	var req createCommentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if err := h.V.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_error"})
+		return
+	}
+
+	cm, err := h.Comments.Create(services.CreateCommentInput{
+		PostSlug: c.Param("slug"),
+		AuthorID: uid,
+		ParentID: req.ParentID,
+		Content:  req.Content,
+		IsAdmin:  canModerate,
+	})
+	if err != nil {
+		writeCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, commentDTO(cm))
+}
+
+type updateCommentReq struct {
+	Content string `json:"content" validate:"required,max=5000"`
+}
+
+func (h CommentHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	uid, _ := middleware.GetAuthUserID(c)
+	canModerate := middleware.HasPermission(c, models.PermCommentsModerate)
+
+	var req updateCommentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if err := h.V.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_error"})
+		return
+	}
+
+	cm, err := h.Comments.Update(services.UpdateCommentInput{
+		PostSlug:  c.Param("slug"),
+		CommentID: uint(id),
+		UserID:    uid,
+		IsAdmin:   canModerate,
+		Content:   req.Content,
+	})
+	if err != nil {
+		writeCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, commentDTO(cm))
+}
+
+func (h CommentHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	uid, _ := middleware.GetAuthUserID(c)
+	canModerate := middleware.HasPermission(c, models.PermCommentsModerate)
+
+	if err := h.Comments.Delete(c.Param("slug"), uint(id), uid, canModerate); err != nil {
+		writeCommentError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// 管理后台：审核队列，默认只看 pending；status=all 查看全部
+func (h CommentHandler) AdminList(c *gin.Context) {
+	page, _ := strconv.Atoi(c.Query("page"))
+	size, _ := strconv.Atoi(c.Query("size"))
+
+	var f repositories.CommentFilter
+	switch statusQ := strings.TrimSpace(c.Query("status")); statusQ {
+	case "":
+		st := models.CommentPending
+		f.Status = &st
+	case "all":
+	case string(models.CommentPending), string(models.CommentApproved),
+		string(models.CommentRejected), string(models.CommentSpam):
+		st := models.CommentStatus(statusQ)
+		f.Status = &st
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+
+	if v := c.Query("post_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+			return
+		}
+		f.PostID = uint(id)
+	}
+	if v := c.Query("author_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+			return
+		}
+		f.AuthorID = uint(id)
+	}
+
+	items, total, err := h.Comments.ListForModeration(f, page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(items))
+	for i := range items {
+		item := commentDTO(&items[i])
+		item["post"] = gin.H{"id": items[i].Post.ID, "title": items[i].Post.Title, "slug": items[i].Post.Slug}
+		out = append(out, item)
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": out})
+}
+
+type moderateCommentsReq struct {
+	IDs    []uint `json:"ids" validate:"required,min=1,max=200,dive,min=1"`
+	Action string `json:"action" validate:"required,oneof=approve reject spam"`
+}
+
+func (h CommentHandler) Moderate(c *gin.Context) {
+	var req moderateCommentsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if err := h.V.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_error"})
+		return
+	}
+
+	n, err := h.Comments.Moderate(req.IDs, req.Action)
+	if err != nil {
+		if err == services.ErrInvalidAction {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": n})
+}
+
+func writeCommentError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrPostNotFound, services.ErrCommentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+	case services.ErrCommentForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	case services.ErrContentRequired, services.ErrInvalidParent:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+	}
+}
+
+// ---- DTO helpers ----
+
+func commentDTO(cm *models.Comment) gin.H {
+	return gin.H{
+		"id":         cm.ID,
+		"post_id":    cm.PostID,
+		"parent_id":  cm.ParentID,
+		"author":     gin.H{"id": cm.Author.ID, "username": cm.Author.Username},
+		"content":    cm.Content,
+		"status":     cm.Status,
+		"created_at": cm.CreatedAt,
+		"updated_at": cm.UpdatedAt,
+	}
+}
+
+func commentTreeDTO(nodes []*services.CommentNode) []gin.H {
+	out := make([]gin.H, 0, len(nodes))
+	for _, n := range nodes {
+		var item gin.H
+		if n.Deleted {
+			// 已删除的评论只保留结构，不暴露内容与作者
+			item = gin.H{
+				"id":         n.Comment.ID,
+				"parent_id":  n.Comment.ParentID,
+				"deleted":    true,
+				"created_at": n.Comment.CreatedAt,
+			}
+		} else {
+			item = commentDTO(&n.Comment)
+			item["deleted"] = false
+		}
+		item["replies"] = commentTreeDTO(n.Replies)
+		out = append(out, item)
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"blog-service/internal/feed"
+	"blog-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeedHandler struct {
+	Feeds *services.FeedService
+}
+
+// GET /feed.xml、/tags/:name/feed.xml、/authors/:username/feed.xml
+func (h FeedHandler) RSS(c *gin.Context) {
+	h.serve(c, feed.RSS, feed.TypeRSS)
+}
+
+// GET /atom.xml、/tags/:name/atom.xml、/authors/:username/atom.xml
+func (h FeedHandler) Atom(c *gin.Context) {
+	h.serve(c, feed.Atom, feed.TypeAtom)
+}
+
+// GET /feed.json、/tags/:name/feed.json、/authors/:username/feed.json
+func (h FeedHandler) JSON(c *gin.Context) {
+	h.serve(c, feed.JSON, feed.TypeJSON)
+}
+
+func (h FeedHandler) serve(c *gin.Context, render func(feed.Feed) ([]byte, error), contentType string) {
+	scope := services.FeedScope{Tag: c.Param("name"), Author: c.Param("username")}
+	f, err := h.Feeds.Build(scope, c.Request.URL.EscapedPath())
+	if err != nil {
+		switch err {
+		case services.ErrTagNotFound, services.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		}
+		return
+	}
+	body, err := render(*f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+
+	serveConditional(c, body, f.Updated, contentType)
+}
+
+// 带 ETag / Last-Modified 输出生成的内容，If-None-Match / If-Modified-Since 命中时返回 304。
+// ETag 取内容摘要（文章下线、删除时 Last-Modified 不一定变化）；modTime 为零时不输出 Last-Modified
+func serveConditional(c *gin.Context, body []byte, modTime time.Time, contentType string) {
+	sum := sha256.Sum256(body)
+	c.Header("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "public, max-age=300")
+	http.ServeContent(c.Writer, c.Request, "", modTime, bytes.NewReader(body))
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	jwtutil "blog-service/internal/utils/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	JWT jwtutil.Manager
+}
+
+func (h JWKSHandler) Get(c *gin.Context) {
+	// 允许短时缓存；轮换时旧公钥会保留一段时间，缓存不会导致验签失败
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.JWT.JWKS())
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"blog-service/internal/middleware"
+	"blog-service/internal/models"
+	"blog-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LikeHandler struct {
+	Likes *services.LikeService
+}
+
+func (h LikeHandler) Like(c *gin.Context) {
+	uid, _ := middleware.GetAuthUserID(c)
+	p, err := h.Likes.Like(c.Param("slug"), uid)
+	h.writeLikeResult(c, p, true, err)
+}
+
+func (h LikeHandler) Unlike(c *gin.Context) {
+	uid, _ := middleware.GetAuthUserID(c)
+	p, err := h.Likes.Unlike(c.Param("slug"), uid)
+	h.writeLikeResult(c, p, false, err)
+}
+
+func (h LikeHandler) MyLikes(c *gin.Context) {
+	uid, _ := middleware.GetAuthUserID(c)
+	page, _ := strconv.Atoi(c.Query("page"))
+	size, _ := strconv.Atoi(c.Query("size"))
+
+	items, total, err := h.Likes.ListLikedPosts(uid, page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": postListDTO(items)})
+}
+
+func (h LikeHandler) writeLikeResult(c *gin.Context, p *models.Post, liked bool, err error) {
+	if err != nil {
+		if err == services.ErrPostNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"liked": liked, "like_count": p.LikeCount})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"blog-service/internal/middleware"
+	"blog-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// TOTP 二次验证：绑定/关闭/恢复码，以及两步登录的第二步
+type MFAHandler struct {
+	Auth *services.AuthService
+	V    *validator.Validate
+}
+
+type mfaVerifyReq struct {
+	MFAToken string `json:"mfa_token" validate:"required,max=255"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
+
+// 两步登录：mfa_token + 验证码（或恢复码）换取正式令牌
+func (h MFAHandler) Verify(c *gin.Context) {
+	var req mfaVerifyReq
+	if !h.bind(c, &req) {
+		return
+	}
+
+	tokens, u, err := h.Auth.VerifyMFA(req.MFAToken, req.Code, c.ClientIP())
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+
+	out := tokenPairDTO(tokens)
+	out["user"] = gin.H{
+		"id":       u.ID,
+		"email":    u.Email,
+		"username": u.Username,
+		"role":     u.Role,
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// 开始绑定：返回密钥与 otpauth 链接
+func (h MFAHandler) Setup(c *gin.Context) {
+	uid, _ := middleware.GetAuthUserID(c)
+	setup, err := h.Auth.SetupMFA(uid)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      setup.Secret,
+		"otpauth_uri": setup.URI,
+	})
+}
+
+type mfaCodeReq struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+// 确认绑定：返回恢复码与新的令牌对（旧会话已吊销）
+func (h MFAHandler) Enable(c *gin.Context) {
+	var req mfaCodeReq
+	if !h.bind(c, &req) {
+		return
+	}
+	uid, _ := middleware.GetAuthUserID(c)
+
+	codes, tokens, err := h.Auth.EnableMFA(uid, req.Code)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	out := tokenPairDTO(tokens)
+	out["recovery_codes"] = codes
+	c.JSON(http.StatusOK, out)
+}
+
+type mfaDisableReq struct {
+	Password string `json:"password" validate:"required,max=72"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
+
+func (h MFAHandler) Disable(c *gin.Context) {
+	var req mfaDisableReq
+	if !h.bind(c, &req) {
+		return
+	}
+	uid, _ := middleware.GetAuthUserID(c)
+
+	if err := h.Auth.DisableMFA(uid, req.Password, req.Code); err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req mfaCodeReq
+	if !h.bind(c, &req) {
+		return
+	}
+	uid, _ := middleware.GetAuthUserID(c)
+
+	codes, err := h.Auth.RegenerateRecoveryCodes(uid, req.Code)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (h MFAHandler) bind(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return false
+	}
+	if err := h.V.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_error"})
+		return false
+	}
+	return true
+}
+
+func writeMFAError(c *gin.Context, err error) {
+	var locked *services.AccountLockedError
+	if errors.As(err, &locked) {
+		middleware.AbortTooManyRequests(c, time.Until(locked.Until).Seconds())
+		return
+	}
+	switch err {
+	case services.ErrInvalidMFAToken, services.ErrInvalidMFACode, services.ErrInvalidCredentials:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case services.ErrAccountDisabled, services.ErrMFARequired:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrMFAAlreadyEnabled, services.ErrMFANotEnabled, services.ErrMFANotSetup:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrUserNotFound:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+	}
+}
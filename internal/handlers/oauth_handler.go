@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"blog-service/internal/middleware"
+	"blog-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oauthStateCookie = "oauth_state"
+	oauthCookiePath  = "/api/v1/auth/oauth"
+)
+
+// 第三方登录（GitHub、OIDC）
+type OAuthHandler struct {
+	OAuth *services.OAuthService
+	// 站点地址为 https 时 cookie 加 Secure
+	SecureCookie bool
+}
+
+// GET /auth/oauth/providers：已启用的提供方，供前端渲染登录按钮
+func (h OAuthHandler) Providers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.OAuth.ProviderNames()})
+}
+
+// GET /auth/oauth/:provider/start：写入 state cookie 并跳转到提供方授权页
+func (h OAuthHandler) Start(c *gin.Context) {
+	authURL, cookie, err := h.OAuth.Begin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		writeOAuthError(c, err)
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, cookie, 600, oauthCookiePath, "", h.SecureCookie, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// GET /auth/oauth/:provider/callback?code=&state=：返回与密码登录相同的令牌
+func (h OAuthHandler) Callback(c *gin.Context) {
+	// state cookie 只用一次
+	cookie, _ := c.Cookie(oauthStateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, "", -1, oauthCookiePath, "", h.SecureCookie, true)
+
+	// 用户在提供方拒绝授权
+	if e := c.Query("error"); e != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "oauth_denied"})
+		return
+	}
+
+	tokens, u, err := h.OAuth.Complete(c.Request.Context(), c.Param("provider"), cookie, c.Query("state"), c.Query("code"))
+	if err != nil {
+		var mfa *services.MFARequiredError
+		if errors.As(err, &mfa) {
+			c.JSON(http.StatusOK, gin.H{
+				"mfa_required": true,
+				"mfa_token":    mfa.Token,
+				"expires_in":   int(mfa.ExpiresIn.Seconds()),
+			})
+			return
+		}
+		writeOAuthError(c, err)
+		return
+	}
+
+	out := tokenPairDTO(tokens)
+	out["user"] = gin.H{
+		"id":             u.ID,
+		"email":          u.Email,
+		"username":       u.Username,
+		"role":           u.Role,
+		"email_verified": u.EmailVerified(),
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// GET /auth/identities：当前用户已绑定的第三方身份
+func (h OAuthHandler) ListIdentities(c *gin.Context) {
+	uid, _ := middleware.GetAuthUserID(c)
+	items, err := h.OAuth.ListIdentities(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+	out := make([]gin.H, 0, len(items))
+	for _, it := range items {
+		out = append(out, gin.H{
+			"id":            it.ID,
+			"provider":      it.Provider,
+			"email":         it.Email,
+			"last_login_at": it.LastLoginAt,
+			"created_at":    it.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"items": out})
+}
+
+// DELETE /auth/identities/:id：解绑
+func (h OAuthHandler) Unlink(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	uid, _ := middleware.GetAuthUserID(c)
+	if err := h.OAuth.Unlink(uid, id); err != nil {
+		writeOAuthError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeOAuthError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrUnknownProvider, services.ErrIdentityNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvalidOAuthState, services.ErrOAuthEmailRequired:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrOAuthFailed:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	case services.ErrEmailTaken, services.ErrLastLoginMethod:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrAccountDisabled, services.ErrPasswordResetReq:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrUserNotFound:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+	}
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"blog-service/internal/models"
+	"blog-service/internal/repositories"
+	"blog-service/internal/services"
+	"blog-service/internal/theme"
+	"blog-service/internal/viewcount"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 列表页每页文章数
+const pageSize = 10
+
+// 服务端渲染的公开页面（首页、文章、标签、分类）
+type PageHandler struct {
+	Theme      *theme.Theme
+	PostRepo   *repositories.PostRepo
+	Tags       *services.TagService
+	Categories *services.CategoryService
+	Views      *viewcount.Counter
+	Site       theme.Site
+}
+
+// GET /?page=
+func (h PageHandler) Home(c *gin.Context) {
+	page, ok := pageParam(c)
+	if !ok {
+		h.NotFound(c)
+		return
+	}
+	items, total, err := h.PostRepo.ListPublished(page, pageSize)
+	if err != nil {
+		h.serverError(c, err)
+		return
+	}
+	if page > 1 && len(items) == 0 {
+		h.NotFound(c)
+		return
+	}
+
+	data := h.listData("/", page, total, items)
+	data.Title = h.Site.Title
+	data.Meta.Title = h.Site.Title
+	data.Meta.Description = h.Site.Description
+	feeds := ""
+	data.Meta.FeedBase = &feeds
+	if page > 1 {
+		data.Title = h.Site.Title + " - 第 " + strconv.Itoa(page) + " 页"
+	}
+	h.render(c, http.StatusOK, theme.PageHome, data)
+}
+
+// GET /posts/:slug
+func (h PageHandler) Post(c *gin.Context) {
+	p, err := h.PostRepo.FindBySlugPublished(c.Param("slug"))
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			h.NotFound(c)
+			return
+		}
+		h.serverError(c, err)
+		return
+	}
+	recordView(c, h.Views, p)
+
+	tags := make([]string, 0, len(p.Tags))
+	for _, t := range p.Tags {
+		tags = append(tags, t.Name)
+	}
+	updated := p.UpdatedAt
+	data := &theme.PageData{
+		Site:  h.Site,
+		Title: p.Title + " - " + h.Site.Title,
+		Meta: theme.Meta{
+			Title:       p.Title,
+			Description: theme.Excerpt(p.ContentHTML, 160),
+			Canonical:   h.Site.BaseURL + "/posts/" + url.PathEscape(p.Slug),
+			Type:        "article",
+			Image:       h.absolute(theme.FirstImage(p.ContentHTML)),
+			Author:      p.Author.Username,
+			Published:   p.PublishedAt,
+			Modified:    &updated,
+			Tags:        tags,
+		},
+		Post: p,
+	}
+	h.render(c, http.StatusOK, theme.PagePost, data)
+}
+
+// GET /tags/:name?page=
+func (h PageHandler) Tag(c *gin.Context) {
+	page, ok := pageParam(c)
+	if !ok {
+		h.NotFound(c)
+		return
+	}
+	name := c.Param("name")
+	items, total, err := h.Tags.ListPosts(name, page, pageSize)
+	if err != nil {
+		if err == services.ErrTagNotFound {
+			h.NotFound(c)
+			return
+		}
+		h.serverError(c, err)
+		return
+	}
+	if page > 1 && len(items) == 0 {
+		h.NotFound(c)
+		return
+	}
+
+	path := "/tags/" + url.PathEscape(name)
+	data := h.listData(path, page, total, items)
+	data.Tag = name
+	data.Title = "#" + name + " - " + h.Site.Title
+	data.Meta.Title = "#" + name
+	data.Meta.Description = "标签 " + name + " 下的文章"
+	data.Meta.FeedBase = &path
+	h.render(c, http.StatusOK, theme.PageTag, data)
+}
+
+// GET /categories/:slug?page=（含子孙分类）
+func (h PageHandler) Category(c *gin.Context) {
+	page, ok := pageParam(c)
+	if !ok {
+		h.NotFound(c)
+		return
+	}
+	cat, items, total, err := h.Categories.ListPosts(c.Param("slug"), page, pageSize)
+	if err != nil {
+		if err == services.ErrCategoryNotFound {
+			h.NotFound(c)
+			return
+		}
+		h.serverError(c, err)
+		return
+	}
+	if page > 1 && len(items) == 0 {
+		h.NotFound(c)
+		return
+	}
+
+	data := h.listData("/categories/"+url.PathEscape(cat.Slug), page, total, items)
+	data.Category = cat
+	data.Title = cat.Name + " - " + h.Site.Title
+	data.Meta.Title = cat.Name
+	data.Meta.Description = cat.Description
+	h.render(c, http.StatusOK, theme.PageCategory, data)
+}
+
+// 404 页面；/api 下仍返回 JSON
+func (h PageHandler) NotFound(c *gin.Context) {
+	if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		return
+	}
+	data := &theme.PageData{
+		Site:  h.Site,
+		Title: "页面不存在 - " + h.Site.Title,
+		Meta: theme.Meta{
+			Title:     "页面不存在",
+			Canonical: h.Site.BaseURL + c.Request.URL.EscapedPath(),
+			Type:      "website",
+		},
+	}
+	h.render(c, http.StatusNotFound, theme.PageNotFound, data)
+}
+
+// 列表页公共部分：分页、canonical、rel=prev/next
+func (h PageHandler) listData(path string, page int, total int64, items []models.Post) *theme.PageData {
+	pages := int((total + pageSize - 1) / pageSize)
+	pager := &theme.Pager{Page: page, TotalPages: pages}
+	meta := theme.Meta{
+		Canonical: h.Site.BaseURL + pageURL(path, page),
+		Type:      "website",
+	}
+	if page > 1 {
+		pager.PrevURL = pageURL(path, page-1)
+		meta.Prev = h.Site.BaseURL + pager.PrevURL
+	}
+	if page < pages {
+		pager.NextURL = pageURL(path, page+1)
+		meta.Next = h.Site.BaseURL + pager.NextURL
+	}
+	return &theme.PageData{Site: h.Site, Meta: meta, Posts: items, Pager: pager}
+}
+
+// 第一页不带 page 参数，避免同一页面出现两个地址
+func pageURL(path string, page int) string {
+	if page <= 1 {
+		return path
+	}
+	return path + "?page=" + strconv.Itoa(page)
+}
+
+// page 参数缺省为 1；非法值视为不存在的页面
+func pageParam(c *gin.Context) (int, bool) {
+	s := c.Query("page")
+	if s == "" {
+		return 1, true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
+
+// 站内相对地址补全为绝对地址（og:image 要求）
+func (h PageHandler) absolute(u string) string {
+	if strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//") {
+		return h.Site.BaseURL + u
+	}
+	return u
+}
+
+func (h PageHandler) render(c *gin.Context, status int, page string, data *theme.PageData) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	// Render 先渲染到缓冲区，出错时还没有写出任何内容
+	if err := h.Theme.Render(c.Writer, page, data); err != nil {
+		h.serverError(c, err)
+	}
+}
+
+func (h PageHandler) serverError(c *gin.Context, err error) {
+	log.Printf("page %s failed: %v", c.Request.URL.Path, err)
+	c.Writer.Header().Del("Content-Type")
+	c.String(http.StatusInternalServerError, "internal server error")
+}
@@ -1,14 +1,19 @@
 package handlers
 
 import (
+	"errors"
+	"hash/fnv"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"blog-service/internal/middleware"
 	"blog-service/internal/models"
 	"blog-service/internal/repositories"
 	"blog-service/internal/services"
+	"blog-service/internal/utils/cursor"
+	"blog-service/internal/viewcount"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -17,14 +22,18 @@ import (
 type PostHandler struct {
 	Posts    *services.PostService
 	PostRepo *repositories.PostRepo // 用于只读/计数等
+	Likes    *repositories.LikeRepo
+	Views    *viewcount.Counter // 为 nil 时不统计浏览量
 	V        *validator.Validate
 }
 
 type createPostReq struct {
-	Title     string            `json:"title" validate:"required,max=200"`
-	ContentMD string            `json:"content_md" validate:"required"`
-	Status    models.PostStatus `json:"status" validate:"required,oneof=draft published"`
-	Tags      []string          `json:"tags"`
+	Title      string            `json:"title" validate:"required,max=200"`
+	ContentMD  string            `json:"content_md" validate:"required"`
+	Status     models.PostStatus `json:"status" validate:"required,oneof=draft published scheduled"`
+	PublishAt  *time.Time        `json:"publish_at"` // status=scheduled 时必填（RFC3339）
+	Tags       []string          `json:"tags"`
+	CategoryID *uint             `json:"category_id"`
 }
 
 func (h PostHandler) Create(c *gin.Context) {
@@ -41,15 +50,20 @@ func (h PostHandler) Create(c *gin.Context) {
 	}
 
 	p, err := h.Posts.Create(services.CreatePostInput{
-		Title:     req.Title,
-		ContentMD: req.ContentMD,
-		Status:    req.Status,
-		Tags:      req.Tags,
-		AuthorID:  uid,
+		Title:      req.Title,
+		ContentMD:  req.ContentMD,
+		Status:     req.Status,
+		PublishAt:  req.PublishAt,
+		Tags:       req.Tags,
+		CategoryID: req.CategoryID,
+		AuthorID:   uid,
+		CanPublish: middleware.HasPermission(c, models.PermPostsPublish),
 	})
 	if err != nil {
 		switch err {
-		case services.ErrInvalidStatus, services.ErrTitleRequired, services.ErrContentRequired:
+		case services.ErrPostForbidden:
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		case services.ErrInvalidStatus, services.ErrTitleRequired, services.ErrContentRequired, services.ErrInvalidCategory, services.ErrInvalidPublishAt:
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
@@ -61,10 +75,12 @@ func (h PostHandler) Create(c *gin.Context) {
 }
 
 type updatePostReq struct {
-	Title     *string            `json:"title" validate:"omitempty,max=200"`
-	ContentMD *string            `json:"content_md"`
-	Status    *models.PostStatus `json:"status" validate:"omitempty,oneof=draft published"`
-	Tags      *[]string          `json:"tags"`
+	Title      *string            `json:"title" validate:"omitempty,max=200"`
+	ContentMD  *string            `json:"content_md"`
+	Status     *models.PostStatus `json:"status" validate:"omitempty,oneof=draft published scheduled"`
+	PublishAt  *time.Time         `json:"publish_at"`
+	Tags       *[]string          `json:"tags"`
+	CategoryID *uint              `json:"category_id"` // 0 表示取消分类
 }
 
 func (h PostHandler) Update(c *gin.Context) {
@@ -84,17 +100,42 @@ func (h PostHandler) Update(c *gin.Context) {
 		return
 	}
 
-	p, err := h.Posts.Update(uint(id), services.UpdatePostInput{
-		Title:     req.Title,
-		ContentMD: req.ContentMD,
-		Status:    req.Status,
-		Tags:      req.Tags,
+	h.update(c, uint(id), services.UpdatePostInput{
+		Title:      req.Title,
+		ContentMD:  req.ContentMD,
+		Status:     req.Status,
+		PublishAt:  req.PublishAt,
+		Tags:       req.Tags,
+		CategoryID: req.CategoryID,
 	})
+}
+
+// POST /admin/posts/:id/publish：立即发布（需要 posts:publish）
+func (h PostHandler) Publish(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	st := models.PostPublished
+	h.update(c, uint(id), services.UpdatePostInput{Status: &st})
+}
+
+// 补全操作者身份与权限后调用 PostService.Update
+func (h PostHandler) update(c *gin.Context, id uint, in services.UpdatePostInput) {
+	uid, _ := middleware.GetAuthUserID(c)
+	in.EditorID = uid
+	in.EditOthers = middleware.HasPermission(c, models.PermPostsEditOthers)
+	in.CanPublish = middleware.HasPermission(c, models.PermPostsPublish)
+
+	p, err := h.Posts.Update(id, in)
 	if err != nil {
 		switch err {
 		case services.ErrPostNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
-		case services.ErrInvalidStatus, services.ErrTitleRequired, services.ErrContentRequired:
+		case services.ErrPostForbidden:
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		case services.ErrInvalidStatus, services.ErrTitleRequired, services.ErrContentRequired, services.ErrInvalidCategory, services.ErrInvalidPublishAt:
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
@@ -111,46 +152,160 @@ func (h PostHandler) Delete(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
 		return
 	}
-	if err := h.PostRepo.DeleteByID(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+	uid, _ := middleware.GetAuthUserID(c)
+
+	if err := h.Posts.Delete(uint(id), uid, middleware.HasPermission(c, models.PermPostsEditOthers)); err != nil {
+		switch err {
+		case services.ErrPostNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		case services.ErrPostForbidden:
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		}
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
+// GET /api/v1/posts?size=&page=|cursor=&total=&tags=&tag_mode=&author=&from=&to=&sort=
+// 传 cursor 时按游标翻页（忽略 page），默认不统计总数；否则按 page 偏移分页，默认返回 total
 func (h PostHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.Query("page"))
 	size, _ := strconv.Atoi(c.Query("size"))
 
-	// admin 才允许带 status 参数查看草稿
+	// 可管理全部文章的角色才允许带 status 参数查看草稿
 	statusQ := strings.TrimSpace(c.Query("status"))
-	role, _ := middleware.GetAuthRole(c)
-
-	if role == "admin" && statusQ != "" {
+	var status *models.PostStatus
+	if middleware.HasPermission(c, models.PermPostsEditOthers) && statusQ != "" {
 		var st models.PostStatus
 		if statusQ == "draft" {
 			st = models.PostDraft
 		} else if statusQ == "published" {
 			st = models.PostPublished
+		} else if statusQ == "scheduled" {
+			st = models.PostScheduled
 		} else {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
 			return
 		}
-		items, total, err := h.PostRepo.ListAny(&st, page, size)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{"total": total, "items": postListDTO(items)})
+		status = &st
+	}
+
+	filter, err := parsePostFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	q := repositories.PageQuery{Page: page, Size: size, Cursor: c.Query("cursor")}
+	switch c.Query("total") {
+	case "":
+		q.Total = q.Cursor == ""
+	case "true", "1":
+		q.Total = true
+	case "false", "0":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
 		return
 	}
 
-	items, total, err := h.PostRepo.ListPublished(page, size)
+	var res *repositories.PostPage
+	if status != nil {
+		res, err = h.PostRepo.ListAnyPage(status, filter, q)
+	} else {
+		res, err = h.PostRepo.ListPublishedPage(filter, q)
+	}
 	if err != nil {
+		if err == cursor.ErrInvalid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"total": total, "items": postListDTO(items)})
+
+	out := gin.H{"items": postListDTO(res.Items)}
+	if res.Total != nil {
+		out["total"] = *res.Total
+	}
+	next, prev := res.Cursors()
+	out["next_cursor"] = next
+	out["prev_cursor"] = prev
+	c.JSON(http.StatusOK, out)
+}
+
+var (
+	errInvalidSort      = errors.New("invalid_sort")
+	errInvalidTagMode   = errors.New("invalid_tag_mode")
+	errTooManyTags      = errors.New("too_many_tags")
+	errInvalidDate      = errors.New("invalid_date")
+	errInvalidDateRange = errors.New("invalid_date_range")
+)
+
+// 单次筛选最多的标签数
+const maxFilterTags = 10
+
+// 解析列表筛选参数；未知取值返回对应错误（400）
+func parsePostFilter(c *gin.Context) (repositories.PostFilter, error) {
+	var f repositories.PostFilter
+
+	f.Sort = repositories.PostSort(c.DefaultQuery("sort", string(repositories.SortNewest)))
+	if !repositories.ValidPostSort(f.Sort) {
+		return f, errInvalidSort
+	}
+
+	seen := map[string]bool{}
+	for _, t := range strings.Split(c.Query("tags"), ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && !seen[t] {
+			seen[t] = true
+			f.Tags = append(f.Tags, t)
+		}
+	}
+	if len(f.Tags) > maxFilterTags {
+		return f, errTooManyTags
+	}
+	switch c.DefaultQuery("tag_mode", "any") {
+	case "any":
+	case "all":
+		f.AllTags = true
+	default:
+		return f, errInvalidTagMode
+	}
+
+	f.Author = strings.TrimSpace(c.Query("author"))
+
+	var err error
+	if f.From, err = parseDateParam(c.Query("from"), false); err != nil {
+		return f, err
+	}
+	if f.To, err = parseDateParam(c.Query("to"), true); err != nil {
+		return f, err
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return f, errInvalidDateRange
+	}
+	return f, nil
+}
+
+// 支持 RFC3339 或 YYYY-MM-DD（按服务器时区）；日期作为结束时间时包含当天
+func parseDateParam(s string, end bool) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return nil, errInvalidDate
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
 }
 
 func (h PostHandler) GetBySlug(c *gin.Context) {
@@ -160,16 +315,23 @@ func (h PostHandler) GetBySlug(c *gin.Context) {
 		return
 	}
 
-	role, _ := middleware.GetAuthRole(c)
+	uid, _ := middleware.GetAuthUserID(c)
 
 	var (
 		p   *models.Post
 		err error
 	)
-	// admin 若带 token，可以看草稿详情
-	if role == "admin" {
+	// 可管理全部文章的角色可以看草稿详情；作者可以看自己的草稿
+	switch {
+	case middleware.HasPermission(c, models.PermPostsEditOthers):
 		p, err = h.PostRepo.FindBySlugAny(slug)
-	} else {
+	case middleware.HasPermission(c, models.PermPostsWrite):
+		p, err = h.PostRepo.FindBySlugAny(slug)
+		if err == nil && p.Status != models.PostPublished && p.AuthorID != uid {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+			return
+		}
+	default:
 		p, err = h.PostRepo.FindBySlugPublished(slug)
 	}
 	if err != nil {
@@ -181,10 +343,18 @@ func (h PostHandler) GetBySlug(c *gin.Context) {
 		return
 	}
 
-	// 浏览量 +1（失败不阻断）
-	_ = h.PostRepo.IncViewCount(p.ID)
+	recordView(c, h.Views, p)
 
-	c.JSON(http.StatusOK, postDetailDTO(p))
+	out := postDetailDTO(p)
+	// 可选鉴权识别出调用者时，附带是否已点赞
+	if uid != 0 && h.Likes != nil {
+		liked, err := h.Likes.IsLiked(p.ID, uid)
+		if err == nil {
+			out["liked_by_me"] = liked
+		}
+	}
+
+	c.JSON(http.StatusOK, out)
 }
 
 type previewReq struct {
@@ -224,6 +394,7 @@ func postListDTO(items []models.Post) []gin.H {
 			"published_at":  p.PublishedAt,
 			"author":        gin.H{"id": p.Author.ID, "username": p.Author.Username},
 			"tags":          tagDTO(p.Tags),
+			"category":      postCategoryDTO(p.Category),
 			"view_count":    p.ViewCount,
 			"like_count":    p.LikeCount,
 			"comment_count": p.CommentCount,
@@ -243,6 +414,7 @@ func postDetailDTO(p *models.Post) gin.H {
 		"published_at":  p.PublishedAt,
 		"author":        gin.H{"id": p.Author.ID, "username": p.Author.Username},
 		"tags":          tagDTO(p.Tags),
+		"category":      postCategoryDTO(p.Category),
 		"content_md":    p.ContentMD,
 		"content_html":  p.ContentHTML,
 		"view_count":    p.ViewCount,
@@ -260,3 +432,21 @@ func tagDTO(tags []models.Tag) []string {
 	}
 	return out
 }
+
+// 计入浏览量：跳过爬虫、未发布文章（预览）以及作者和编辑自己的访问；
+// 同一访客（登录用户按 id，否则按 IP + UA）在去重窗口内只算一次
+func recordView(c *gin.Context, views *viewcount.Counter, p *models.Post) {
+	if views == nil || p.Status != models.PostPublished || viewcount.IsBot(c.Request.UserAgent()) {
+		return
+	}
+	if uid, ok := middleware.GetAuthUserID(c); ok {
+		if uid == p.AuthorID || middleware.HasPermission(c, models.PermPostsEditOthers) {
+			return
+		}
+		views.Hit(p.ID, "user:"+strconv.FormatUint(uint64(uid), 10))
+		return
+	}
+	h := fnv.New64a()
+	h.Write([]byte(c.ClientIP() + "\x00" + c.Request.UserAgent()))
+	views.Hit(p.ID, "anon:"+strconv.FormatUint(h.Sum64(), 36))
+}
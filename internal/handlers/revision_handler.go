@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"blog-service/internal/middleware"
+	"blog-service/internal/models"
+	"blog-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RevisionHandler struct {
+	Posts *services.PostService
+}
+
+func (h RevisionHandler) List(c *gin.Context) {
+	postID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	uid, _ := middleware.GetAuthUserID(c)
+
+	items, err := h.Posts.ListRevisions(postID, uid, middleware.HasPermission(c, models.PermPostsEditOthers))
+	if err != nil {
+		writeRevisionError(c, err)
+		return
+	}
+	out := make([]gin.H, 0, len(items))
+	for i := range items {
+		out = append(out, revisionDTO(&items[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": out})
+}
+
+func (h RevisionHandler) Get(c *gin.Context) {
+	postID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	revID, ok := parseIDParam(c, "rid")
+	if !ok {
+		return
+	}
+	uid, _ := middleware.GetAuthUserID(c)
+
+	rev, err := h.Posts.GetRevision(postID, revID, uid, middleware.HasPermission(c, models.PermPostsEditOthers))
+	if err != nil {
+		writeRevisionError(c, err)
+		return
+	}
+	item := revisionDTO(rev)
+	item["content_md"] = rev.ContentMD
+	c.JSON(http.StatusOK, item)
+}
+
+// GET /revisions/diff?from=1&to=2
+func (h RevisionHandler) Diff(c *gin.Context) {
+	postID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	from, err1 := strconv.ParseUint(c.Query("from"), 10, 64)
+	to, err2 := strconv.ParseUint(c.Query("to"), 10, 64)
+	if err1 != nil || err2 != nil || from == 0 || to == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+
+	uid, _ := middleware.GetAuthUserID(c)
+
+	d, err := h.Posts.DiffRevisions(postID, uint(from), uint(to), uid, middleware.HasPermission(c, models.PermPostsEditOthers))
+	if err != nil {
+		writeRevisionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from": revisionDTO(d.From),
+		"to":   revisionDTO(d.To),
+		"diff": d.Unified,
+	})
+}
+
+func (h RevisionHandler) Restore(c *gin.Context) {
+	postID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	revID, ok := parseIDParam(c, "rid")
+	if !ok {
+		return
+	}
+	uid, _ := middleware.GetAuthUserID(c)
+
+	p, err := h.Posts.RestoreRevision(postID, revID, uid, middleware.HasPermission(c, models.PermPostsEditOthers))
+	if err != nil {
+		writeRevisionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, postDetailDTO(p))
+}
+
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeRevisionError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrPostNotFound, services.ErrRevisionNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+	case services.ErrPostForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	case services.ErrTitleRequired, services.ErrContentRequired:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+	}
+}
+
+func revisionDTO(rev *models.PostRevision) gin.H {
+	tags := []string(rev.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	return gin.H{
+		"id":         rev.ID,
+		"post_id":    rev.PostID,
+		"title":      rev.Title,
+		"tags":       tags,
+		"editor":     gin.H{"id": rev.Editor.ID, "username": rev.Editor.Username},
+		"created_at": rev.CreatedAt,
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blog-service/internal/middleware"
+	"blog-service/internal/models"
+	"blog-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	Search *services.SearchService
+}
+
+// GET /api/v1/search?q=&mode=natural|boolean&page=&size=
+func (h SearchHandler) Query(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" || len(q) > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+
+	var boolean bool
+	switch c.DefaultQuery("mode", "natural") {
+	case "natural":
+	case "boolean":
+		boolean = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	size, _ := strconv.Atoi(c.Query("size"))
+
+	results, total, err := h.Search.Search(services.SearchInput{
+		Query:   q,
+		Boolean: boolean,
+		// 匿名/普通用户只能搜到已发布文章
+		OnlyPublished: !middleware.HasPermission(c, models.PermPostsEditOthers),
+		Page:          page,
+		Size:          size,
+	})
+	if err != nil {
+		if err == services.ErrQueryRequired {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+
+	items := make([]gin.H, 0, len(results))
+	for _, r := range results {
+		p := r.Post
+		items = append(items, gin.H{
+			"id":              p.ID,
+			"title":           p.Title,
+			"title_highlight": r.TitleHighlight,
+			"slug":            p.Slug,
+			"status":          p.Status,
+			"published_at":    p.PublishedAt,
+			"author":          gin.H{"id": p.Author.ID, "username": p.Author.Username},
+			"tags":            tagDTO(p.Tags),
+			"snippet":         r.Snippet,
+			"score":           r.Score,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": items})
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"blog-service/internal/services"
+	"blog-service/internal/sitemap"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SitemapHandler struct {
+	Sitemaps *services.SitemapService
+}
+
+// GET /sitemap.xml：sitemap 索引
+func (h SitemapHandler) Index(c *gin.Context) {
+	h.serve(c, "index")
+}
+
+// GET /sitemaps/:name（pages.xml、posts-1.xml ...）
+func (h SitemapHandler) Get(c *gin.Context) {
+	h.serve(c, c.Param("name"))
+}
+
+func (h SitemapHandler) serve(c *gin.Context, name string) {
+	body, modTime, err := h.Sitemaps.Get(name)
+	if err != nil {
+		if err == services.ErrSitemapNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+	serveConditional(c, body, modTime, sitemap.ContentType)
+}
+
+// GET /robots.txt：内容在启动时确定
+func Robots(body []byte) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=3600")
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", body)
+	}
+}
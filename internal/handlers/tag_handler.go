@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"blog-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type TagHandler struct {
+	Tags *services.TagService
+	V    *validator.Validate
+}
+
+// GET /api/v1/tags?sort=popular|name
+func (h TagHandler) List(c *gin.Context) {
+	sort := c.DefaultQuery("sort", "popular")
+	if sort != "popular" && sort != "name" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+
+	items, err := h.Tags.List(sort)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(items))
+	for _, t := range items {
+		out = append(out, gin.H{"id": t.ID, "name": t.Name, "post_count": t.PostCount})
+	}
+	c.JSON(http.StatusOK, gin.H{"items": out})
+}
+
+// GET /api/v1/tags/:name/posts?page=&size=
+func (h TagHandler) Posts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.Query("page"))
+	size, _ := strconv.Atoi(c.Query("size"))
+
+	items, total, err := h.Tags.ListPosts(c.Param("name"), page, size)
+	if err != nil {
+		writeTagError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": postListDTO(items)})
+}
+
+type renameTagReq struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+func (h TagHandler) Rename(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+
+	var req renameTagReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if err := h.V.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_error"})
+		return
+	}
+
+	t, err := h.Tags.Rename(uint(id), req.Name)
+	if err != nil {
+		writeTagError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": t.ID, "name": t.Name})
+}
+
+type mergeTagReq struct {
+	IntoID uint `json:"into_id" validate:"required,min=1"`
+}
+
+// POST /api/v1/admin/tags/:id/merge：把 :id 合并进 into_id
+func (h TagHandler) Merge(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+
+	var req mergeTagReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if err := h.V.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_error"})
+		return
+	}
+
+	t, err := h.Tags.Merge(uint(id), req.IntoID)
+	if err != nil {
+		writeTagError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": t.ID, "name": t.Name})
+}
+
+func (h TagHandler) DeleteUnused(c *gin.Context) {
+	n, err := h.Tags.DeleteUnused()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": n})
+}
+
+func writeTagError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrTagNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+	case services.ErrTagExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrTagNameRequired, services.ErrSameTag:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"blog-service/internal/middleware"
+	"blog-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UploadHandler struct {
+	Media    *services.MediaService
+	MaxBytes int64
+}
+
+// POST /api/v1/admin/uploads（multipart，字段名 file）
+func (h UploadHandler) Upload(c *gin.Context) {
+	uid, _ := middleware.GetAuthUserID(c)
+
+	// 整个请求体也做上限，避免超大 multipart 占满磁盘/内存
+	if h.MaxBytes > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.MaxBytes+(1<<20))
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file_too_large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if h.MaxBytes > 0 && fh.Size > h.MaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file_too_large"})
+		return
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	defer f.Close()
+
+	m, created, err := h.Media.Upload(c.Request.Context(), f, fh.Filename, uid)
+	if err != nil {
+		switch err {
+		case services.ErrFileTooLarge:
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case services.ErrUnsupportedType:
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		case services.ErrEmptyFile:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	url := h.Media.URL(m)
+	c.JSON(status, gin.H{
+		"id":        m.ID,
+		"url":       url,
+		"hash":      m.Hash,
+		"mime_type": m.MimeType,
+		"size":      m.Size,
+		// media: 引用在渲染时由 markdown 包改写为存储后端的公开地址
+		"markdown": fmt.Sprintf("![%s](media:%s)", m.OriginalName, m.Path),
+	})
+}
+
+// 静态访问上传文件：路径内容寻址，可长期强缓存
+func ServeUploads(dir string) gin.HandlerFunc {
+	fs := http.StripPrefix("/uploads", http.FileServer(gin.Dir(dir, false)))
+	return func(c *gin.Context) {
+		c.Header("X-Content-Type-Options", "nosniff")
+		fs.ServeHTTP(&immutableWriter{ResponseWriter: c.Writer}, c.Request)
+	}
+}
+
+// 只在文件成功返回时加强缓存头，404 等错误响应不能被长期缓存
+type immutableWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *immutableWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		switch code {
+		case http.StatusOK, http.StatusPartialContent, http.StatusNotModified:
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *immutableWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
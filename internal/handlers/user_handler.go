@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blog-service/internal/middleware"
+	"blog-service/internal/models"
+	"blog-service/internal/repositories"
+	"blog-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// 管理后台：用户管理
+type UserHandler struct {
+	Users *services.UserService
+	V     *validator.Validate
+}
+
+// GET /admin/users?q=&role=&disabled=&page=&size=
+func (h UserHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.Query("page"))
+	size, _ := strconv.Atoi(c.Query("size"))
+
+	f := repositories.UserFilter{Query: c.Query("q")}
+	if len(f.Query) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if v := strings.TrimSpace(c.Query("role")); v != "" {
+		role := models.UserRole(v)
+		if !role.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_role"})
+			return
+		}
+		f.Role = &role
+	}
+	if v := c.Query("disabled"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+			return
+		}
+		f.Disabled = &b
+	}
+
+	items, total, err := h.Users.List(f, page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+		return
+	}
+	out := make([]gin.H, 0, len(items))
+	for i := range items {
+		out = append(out, adminUserDTO(&items[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": out})
+}
+
+func (h UserHandler) Get(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	u, err := h.Users.Get(id)
+	if err != nil {
+		writeUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, adminUserDTO(u))
+}
+
+type changeRoleReq struct {
+	Role string `json:"role" validate:"required,oneof=admin editor author moderator user"`
+}
+
+func (h UserHandler) ChangeRole(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req changeRoleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+		return
+	}
+	if err := h.V.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_error"})
+		return
+	}
+	uid, _ := middleware.GetAuthUserID(c)
+
+	u, err := h.Users.ChangeRole(uid, id, models.UserRole(req.Role))
+	if err != nil {
+		writeUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, adminUserDTO(u))
+}
+
+func (h UserHandler) Disable(c *gin.Context) {
+	h.setDisabled(c, true)
+}
+
+func (h UserHandler) Enable(c *gin.Context) {
+	h.setDisabled(c, false)
+}
+
+func (h UserHandler) setDisabled(c *gin.Context, disabled bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	uid, _ := middleware.GetAuthUserID(c)
+
+	u, err := h.Users.SetDisabled(uid, id, disabled)
+	if err != nil {
+		writeUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, adminUserDTO(u))
+}
+
+// 强制重置密码：吊销会话并发送重置邮件
+func (h UserHandler) ForcePasswordReset(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.Users.ForcePasswordReset(id); err != nil {
+		writeUserError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DELETE /admin/users/:id?reassign_to=ID；不传 reassign_to 时归属转给当前管理员
+func (h UserHandler) Delete(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	uid, _ := middleware.GetAuthUserID(c)
+
+	reassignTo := uid
+	if v := c.Query("reassign_to"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || n == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad_request"})
+			return
+		}
+		reassignTo = uint(n)
+	}
+
+	if err := h.Users.Delete(uid, id, reassignTo); err != nil {
+		writeUserError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeUserError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+	case services.ErrInvalidRole, services.ErrInvalidReassign:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrCannotModifySelf, services.ErrLastAdmin:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+	}
+}
+
+func adminUserDTO(u *models.User) gin.H {
+	return gin.H{
+		"id":                      u.ID,
+		"email":                   u.Email,
+		"username":                u.Username,
+		"role":                    u.Role,
+		"email_verified_at":       u.EmailVerifiedAt,
+		"disabled":                u.DisabledAt != nil,
+		"disabled_at":             u.DisabledAt,
+		"password_reset_required": u.PasswordResetRequired,
+		"mfa_enabled":             u.MFAEnabled(),
+		"last_login_at":           u.LastLoginAt,
+		"created_at":              u.CreatedAt,
+	}
+}
@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"blog-service/internal/utils/slug"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// 发信抽象：生产可接 SMTP/第三方服务，本地开发用 log/file 实现
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// 根据配置创建 Mailer：file 写入目录，其余一律打印日志
+func New(kind, dir string) Mailer {
+	if strings.EqualFold(kind, "file") {
+		return FileMailer{Dir: dir}
+	}
+	return LogMailer{}
+}
+
+// 把邮件内容打到日志里
+type LogMailer struct{}
+
+func (LogMailer) Send(msg Message) error {
+	log.Printf("mail to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// 每封邮件写成一个 .eml 文件，方便本地查看
+type FileMailer struct {
+	Dir string
+}
+
+func (m FileMailer) Send(msg Message) error {
+	if err := os.MkdirAll(filepath.Clean(m.Dir), 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405"), slug.RandSuffix(4))
+	content := fmt.Sprintf("To: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		msg.To, msg.Subject, time.Now().Format(time.RFC1123Z), msg.Body)
+	return os.WriteFile(filepath.Join(m.Dir, name), []byte(content), 0o600)
+}
@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"blog-service/internal/models"
 	jwtutil "blog-service/internal/utils/jwt"
 
 	"github.com/gin-gonic/gin"
@@ -12,16 +14,43 @@ import (
 const (
 	ctxUserIDKey = "auth.user_id"
 	ctxRoleKey   = "auth.role"
+	ctxClaimsKey = "auth.claims"
+	// 管理员会话因未经二次验证被降权
+	ctxMFARequiredKey = "auth.mfa_required"
 )
 
+// 吊销检查：按 jti 或会话 sid 判断 access token 是否已失效
+type RevocationChecker interface {
+	IsRevoked(jti, sid string) (bool, error)
+}
+
+// 账号状态检查：返回用户当前角色；账号不存在或已停用时 active=false
+type UserStateChecker interface {
+	ActiveRole(userID uint) (role models.UserRole, active bool, err error)
+}
+
+// 邮箱验证状态检查
+type EmailVerifiedChecker interface {
+	EmailVerified(userID uint) (bool, error)
+}
+
 type AuthMiddleware struct {
-	JWT jwtutil.Manager
+	JWT     jwtutil.Manager
+	Revoked RevocationChecker // 为 nil 时不检查
+	Users   UserStateChecker  // 为 nil 时不检查，角色取 token 中的值
+	// 要求管理员使用二次验证登录；未经 MFA 的管理员会话按普通用户处理
+	RequireAdminMFA bool
 }
 
-func NewAuthMiddleware(m jwtutil.Manager) AuthMiddleware {
-	return AuthMiddleware{JWT: m}
+func NewAuthMiddleware(m jwtutil.Manager, rc RevocationChecker, us UserStateChecker) AuthMiddleware {
+	return AuthMiddleware{JWT: m, Revoked: rc, Users: us}
 }
 
+var (
+	errTokenRevoked    = errors.New("token_revoked")
+	errAccountDisabled = errors.New("account_disabled")
+)
+
 func (a AuthMiddleware) AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
@@ -31,29 +60,126 @@ func (a AuthMiddleware) AuthRequired() gin.HandlerFunc {
 		}
 		token := strings.TrimSpace(auth[len("Bearer "):])
 
-		claims, err := a.JWT.Parse(token)
-		if err != nil {
+		claims, err := a.authenticate(token)
+		switch {
+		case err == nil:
+		case errors.Is(err, jwtutil.ErrInvalidToken):
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 			return
+		case errors.Is(err, errTokenRevoked), errors.Is(err, errAccountDisabled):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+			return
 		}
 
-		c.Set(ctxUserIDKey, claims.UserID)
-		c.Set(ctxRoleKey, claims.Role)
+		a.enforceMFA(c, claims)
+		setAuth(c, claims)
 		c.Next()
 	}
 }
 
+// 未经二次验证的管理员会话降为普通用户，访问管理接口时提示 mfa_required；
+// 绑定 MFA 后会话会自动换成带 mfa 标记的新 token
+func (a AuthMiddleware) enforceMFA(c *gin.Context, claims *jwtutil.Claims) {
+	if a.RequireAdminMFA && !claims.MFA && models.UserRole(claims.Role) == models.RoleAdmin {
+		claims.Role = string(models.RoleUser)
+		c.Set(ctxMFARequiredKey, true)
+	}
+}
+
+// 校验签名、吊销状态与账号状态；账号角色以数据库为准，改角色无需重新登录
+func (a AuthMiddleware) authenticate(token string) (*jwtutil.Claims, error) {
+	claims, err := a.JWT.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if a.Revoked != nil {
+		revoked, err := a.Revoked.IsRevoked(claims.ID, claims.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, errTokenRevoked
+		}
+	}
+	if a.Users != nil {
+		role, active, err := a.Users.ActiveRole(claims.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, errAccountDisabled
+		}
+		claims.Role = string(role)
+	}
+	return claims, nil
+}
+
+func setAuth(c *gin.Context, claims *jwtutil.Claims) {
+	c.Set(ctxUserIDKey, claims.UserID)
+	c.Set(ctxRoleKey, claims.Role)
+	c.Set(ctxClaimsKey, claims)
+}
+
 func RequireAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, _ := c.Get(ctxRoleKey)
-		if roleStr, ok := role.(string); !ok || roleStr != "admin" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		if roleStr, ok := role.(string); !ok || models.UserRole(roleStr) != models.RoleAdmin {
+			abortForbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// 按角色权限表放行，如 RequirePermission(models.PermPostsPublish)
+func RequirePermission(p models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, p) {
+			abortForbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// 要求已验证邮箱（评论、点赞等）；需放在 AuthRequired 之后
+func RequireVerifiedEmail(vc EmailVerifiedChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := GetAuthUserID(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		verified, err := vc.EmailVerified(uid)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+			return
+		}
+		if !verified {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "email_not_verified"})
 			return
 		}
 		c.Next()
 	}
 }
 
+func abortForbidden(c *gin.Context) {
+	if c.GetBool(ctxMFARequiredKey) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "mfa_required"})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+}
+
+// 当前请求的身份是否拥有某权限（未登录时为 false）
+func HasPermission(c *gin.Context, p models.Permission) bool {
+	role, ok := GetAuthRole(c)
+	return ok && models.UserRole(role).Can(p)
+}
+
 func GetAuthUserID(c *gin.Context) (uint, bool) {
 	v, ok := c.Get(ctxUserIDKey)
 	if !ok {
@@ -71,3 +197,13 @@ func GetAuthRole(c *gin.Context) (string, bool) {
 	s, ok := v.(string)
 	return s, ok
 }
+
+// 当前请求的 access token claims（登出时用来吊销）
+func GetAuthClaims(c *gin.Context) (*jwtutil.Claims, bool) {
+	v, ok := c.Get(ctxClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(*jwtutil.Claims)
+	return claims, ok
+}
@@ -8,7 +8,12 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func NewOptionalAuth(m jwtutil.Manager) gin.HandlerFunc {
+func NewOptionalAuth(m jwtutil.Manager, rc RevocationChecker, us UserStateChecker) gin.HandlerFunc {
+	return NewAuthMiddleware(m, rc, us).Optional()
+}
+
+// 有合法、未吊销且账号可用的 token 时写入身份，否则按匿名继续
+func (a AuthMiddleware) Optional() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if auth == "" || !strings.HasPrefix(strings.ToLower(auth), "bearer ") {
@@ -16,13 +21,13 @@ func NewOptionalAuth(m jwtutil.Manager) gin.HandlerFunc {
 			return
 		}
 		token := strings.TrimSpace(auth[len("Bearer "):])
-		claims, err := m.Parse(token)
+		claims, err := a.authenticate(token)
 		if err != nil {
 			c.Next()
 			return
 		}
-		c.Set(ctxUserIDKey, claims.UserID)
-		c.Set(ctxRoleKey, claims.Role)
+		a.enforceMFA(c, claims)
+		setAuth(c, claims)
 		c.Next()
 	}
 }
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// 分类：树形结构，一篇文章只属于一个分类
+type Category struct {
+	ID uint `gorm:"primaryKey"`
+
+	Name        string `gorm:"size:50;not null"`
+	Slug        string `gorm:"size:80;not null;uniqueIndex"`
+	Description string `gorm:"size:255;not null;default:''"`
+
+	ParentID *uint     `gorm:"index"`
+	Parent   *Category `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+
+	SortOrder int `gorm:"not null;default:0"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -7,6 +7,8 @@ type CommentStatus string
 const (
 	CommentPending  CommentStatus = "pending"
 	CommentApproved CommentStatus = "approved"
+	CommentRejected CommentStatus = "rejected"
+	CommentSpam     CommentStatus = "spam"
 )
 
 type Comment struct {
@@ -20,7 +22,7 @@ type Comment struct {
 
 	ParentID *uint         `gorm:"index"`
 	Content  string        `gorm:"type:text;not null"`
-	Status   CommentStatus `gorm:"type:enum('pending','approved');not null;default:'approved';index"`
+	Status   CommentStatus `gorm:"type:enum('pending','approved','rejected','spam');not null;default:'approved';index"`
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// 账号整体计数行的 IP 取值
+const LoginFailureAnyIP = "*"
+
+// 登录失败计数与锁定（指数退避），按账号 + 客户端 IP 记录；
+// IP 为 LoginFailureAnyIP 的一行是该账号在所有 IP 上的累计，用于拦截更换 IP 的撞库
+type LoginFailure struct {
+	ID uint `gorm:"primaryKey"`
+
+	UserID      uint   `gorm:"not null;uniqueIndex:uk_login_failures_user_ip,priority:1"`
+	IP          string `gorm:"size:45;not null;uniqueIndex:uk_login_failures_user_ip,priority:2"`
+	Failures    uint   `gorm:"not null;default:0"`
+	LockedUntil *time.Time
+
+	UpdatedAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// 上传的媒体文件：按内容 SHA-256 去重，同一文件只存一份
+type Media struct {
+	ID uint `gorm:"primaryKey"`
+
+	Hash         string `gorm:"size:64;not null;uniqueIndex"`
+	Path         string `gorm:"size:255;not null"` // 相对存储根目录的路径（内容寻址）
+	MimeType     string `gorm:"size:100;not null"`
+	Size         int64  `gorm:"not null"`
+	OriginalName string `gorm:"size:255;not null;default:''"`
+
+	UploaderID uint `gorm:"not null;index"`
+
+	CreatedAt time.Time
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// 二次验证恢复码：一次性使用，库里只存哈希
+type MFARecoveryCode struct {
+	ID uint `gorm:"primaryKey"`
+
+	UserID   uint   `gorm:"not null;index"`
+	CodeHash string `gorm:"size:64;not null;uniqueIndex"`
+	UsedAt   *time.Time
+
+	CreatedAt time.Time
+}
+
+// 两步登录的中间态：密码校验通过后签发 mfa_token，凭它加验证码换取正式令牌
+type MFAChallenge struct {
+	ID uint `gorm:"primaryKey"`
+
+	UserID    uint      `gorm:"not null;index"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	// 验证码错误次数，超过上限后作废，防止暴力猜码
+	Attempts uint `gorm:"not null;default:0"`
+	UsedAt   *time.Time
+
+	CreatedAt time.Time
+}
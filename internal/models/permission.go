@@ -0,0 +1,47 @@
+package models
+
+type Permission string
+
+const (
+	PermPostsWrite       Permission = "posts:write"       // 创建文章、编辑/删除自己的文章
+	PermPostsPublish     Permission = "posts:publish"     // 发布/定时发布
+	PermPostsEditOthers  Permission = "posts:edit_others" // 编辑/删除他人文章，查看全部草稿
+	PermCommentsModerate Permission = "comments:moderate"
+	PermTaxonomyManage   Permission = "taxonomy:manage" // 标签与分类
+	PermMediaUpload      Permission = "media:upload"
+	PermUsersManage      Permission = "users:manage"
+)
+
+// 角色权限表；admin 拥有全部权限，不在表中列出
+var rolePermissions = map[UserRole][]Permission{
+	RoleEditor: {
+		PermPostsWrite, PermPostsPublish, PermPostsEditOthers,
+		PermTaxonomyManage, PermMediaUpload,
+	},
+	RoleAuthor: {
+		PermPostsWrite, PermMediaUpload,
+	},
+	RoleModerator: {
+		PermCommentsModerate,
+	},
+}
+
+func (r UserRole) Can(p Permission) bool {
+	if r == RoleAdmin {
+		return true
+	}
+	for _, rp := range rolePermissions[r] {
+		if rp == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (r UserRole) Valid() bool {
+	switch r {
+	case RoleAdmin, RoleEditor, RoleAuthor, RoleModerator, RoleUser:
+		return true
+	}
+	return false
+}
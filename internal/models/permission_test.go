@@ -0,0 +1,41 @@
+package models
+
+import "testing"
+
+func TestRoleCan(t *testing.T) {
+	all := []Permission{
+		PermPostsWrite, PermPostsPublish, PermPostsEditOthers,
+		PermCommentsModerate, PermTaxonomyManage, PermMediaUpload, PermUsersManage,
+	}
+	allowed := map[UserRole][]Permission{
+		RoleAdmin:     all,
+		RoleEditor:    {PermPostsWrite, PermPostsPublish, PermPostsEditOthers, PermTaxonomyManage, PermMediaUpload},
+		RoleAuthor:    {PermPostsWrite, PermMediaUpload},
+		RoleModerator: {PermCommentsModerate},
+		RoleUser:      nil,
+		// 未知角色没有任何权限
+		UserRole("guest"): nil,
+	}
+	for role, perms := range allowed {
+		want := make(map[Permission]bool, len(perms))
+		for _, p := range perms {
+			want[p] = true
+		}
+		for _, p := range all {
+			if got := role.Can(p); got != want[p] {
+				t.Errorf("%s.Can(%s) = %v, want %v", role, p, got, want[p])
+			}
+		}
+	}
+}
+
+func TestRoleValid(t *testing.T) {
+	for _, r := range []UserRole{RoleAdmin, RoleEditor, RoleAuthor, RoleModerator, RoleUser} {
+		if !r.Valid() {
+			t.Errorf("%s should be valid", r)
+		}
+	}
+	if UserRole("guest").Valid() || UserRole("").Valid() {
+		t.Error("unknown role should be invalid")
+	}
+}
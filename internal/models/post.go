@@ -7,6 +7,7 @@ type PostStatus string
 const (
 	PostDraft     PostStatus = "draft"
 	PostPublished PostStatus = "published"
+	PostScheduled PostStatus = "scheduled" // 定时发布：到 PublishedAt 时由后台任务转为 published
 )
 
 type Post struct {
@@ -16,20 +17,25 @@ type Post struct {
 	Slug        string     `gorm:"size:220;not null;uniqueIndex"`
 	ContentMD   string     `gorm:"type:longtext;not null"`
 	ContentHTML string     `gorm:"type:longtext;not null"`
-	Status      PostStatus `gorm:"type:enum('draft','published');not null;default:'draft';index"`
+	Status      PostStatus `gorm:"type:enum('draft','published','scheduled');not null;default:'draft';index;index:idx_posts_status_published,priority:1;index:idx_posts_status_created,priority:1;index:idx_posts_status_views,priority:1;index:idx_posts_status_likes,priority:1;index:idx_posts_status_comments,priority:1"`
 
-	PublishedAt *time.Time `gorm:"index"`
+	// 列表按 (published_at, id) / (created_at, id) 键集分页，复合索引见 idx_posts_status_*（InnoDB 二级索引隐含主键）
+	PublishedAt *time.Time `gorm:"index;index:idx_posts_status_published,priority:2"`
 
 	AuthorID uint `gorm:"not null;index"`
 	Author   User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
 
-	ViewCount    uint64 `gorm:"not null;default:0"`
-	LikeCount    uint64 `gorm:"not null;default:0"`
-	CommentCount uint64 `gorm:"not null;default:0"`
+	// 按浏览/点赞/评论数排序走 (status, *_count, id)
+	ViewCount    uint64 `gorm:"not null;default:0;index:idx_posts_status_views,priority:2"`
+	LikeCount    uint64 `gorm:"not null;default:0;index:idx_posts_status_likes,priority:2"`
+	CommentCount uint64 `gorm:"not null;default:0;index:idx_posts_status_comments,priority:2"`
+
+	CategoryID *uint     `gorm:"index"`
+	Category   *Category `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 
 	Tags []Tag `gorm:"many2many:post_tags;"`
 
-	CreatedAt time.Time
+	CreatedAt time.Time `gorm:"index:idx_posts_status_created,priority:2"`
 	UpdatedAt time.Time
 	DeletedAt *time.Time `gorm:"index"`
 }
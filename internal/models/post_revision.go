@@ -0,0 +1,56 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 文章修订历史：每次创建/更新都会写入一条完整快照
+type PostRevision struct {
+	ID uint `gorm:"primaryKey"`
+
+	PostID uint `gorm:"not null;index"`
+	Post   Post `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Title     string     `gorm:"size:200;not null"`
+	ContentMD string     `gorm:"type:longtext;not null"`
+	Tags      StringList `gorm:"column:tag_names;type:text"` // 标签名（JSON 数组）
+
+	EditorID uint `gorm:"not null;index"`
+	Editor   User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+
+	CreatedAt time.Time `gorm:"index"`
+}
+
+// 以 JSON 数组存储的字符串列表（元素本身可以包含逗号）
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]string(l))
+	return string(b), err
+}
+
+func (l *StringList) Scan(v any) error {
+	var b []byte
+	switch v := v.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("StringList: unsupported type %T", v)
+	}
+	if len(b) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(l))
+}
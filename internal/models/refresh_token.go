@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// 刷新令牌：不透明随机串，库里只存哈希；每次刷新轮换，
+// 同一次登录派生出的令牌共享 FamilyID（即 access token 中的 sid）
+type RefreshToken struct {
+	ID uint `gorm:"primaryKey"`
+
+	UserID    uint      `gorm:"not null;index"`
+	FamilyID  string    `gorm:"size:64;not null;index"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	// 该会话登录时是否通过了二次验证，轮换时沿用
+	MFA bool `gorm:"not null;default:false"`
+
+	CreatedAt time.Time
+}
+
+type RevocationKind string
+
+const (
+	RevokeJTI     RevocationKind = "jti" // 单个 access token
+	RevokeSession RevocationKind = "sid" // 整个令牌家族签发的 access token
+)
+
+// 已吊销的 access token；过期后可清理
+type TokenRevocation struct {
+	ID uint `gorm:"primaryKey"`
+
+	Kind      RevocationKind `gorm:"type:enum('jti','sid');not null;uniqueIndex:uk_token_revocations_kind_value,priority:1"`
+	Value     string         `gorm:"size:64;not null;uniqueIndex:uk_token_revocations_kind_value,priority:2"`
+	ExpiresAt time.Time      `gorm:"not null;index"`
+
+	CreatedAt time.Time
+}
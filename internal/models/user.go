@@ -5,8 +5,11 @@ import "time"
 type UserRole string
 
 const (
-	RoleAdmin UserRole = "admin"
-	RoleUser  UserRole = "user"
+	RoleAdmin     UserRole = "admin"
+	RoleEditor    UserRole = "editor"    // 管理所有文章
+	RoleAuthor    UserRole = "author"    // 只能管理自己的文章
+	RoleModerator UserRole = "moderator" // 只能审核评论
+	RoleUser      UserRole = "user"
 )
 
 type User struct {
@@ -14,8 +17,29 @@ type User struct {
 	Email        string     `gorm:"size:255;not null;uniqueIndex"`
 	Username     string     `gorm:"size:50;not null;uniqueIndex"`
 	PasswordHash string     `gorm:"size:255;not null"`
-	Role         UserRole   `gorm:"type:enum('admin','user');not null;default:'user';index"`
+	Role         UserRole   `gorm:"type:enum('admin','editor','author','moderator','user');not null;default:'user';index"`
 	LastLoginAt  *time.Time `gorm:"index"`
+	// 邮箱验证时间；为空表示未验证，配置要求时不能评论/点赞
+	EmailVerifiedAt *time.Time
+	// 最近一次发送验证邮件的时间（重发限频）
+	VerificationSentAt *time.Time
+	// 停用时间；非空时禁止登录，已签发的 token 也立即失效
+	DisabledAt *time.Time `gorm:"index"`
+	// 管理员强制重置：置位后必须通过重置邮件设置新密码才能登录
+	PasswordResetRequired bool `gorm:"not null;default:false"`
+	// TOTP 密钥（Base32）；MFAEnabledAt 为空时表示尚在绑定中
+	TOTPSecret   string `gorm:"size:64;not null;default:''"`
+	MFAEnabledAt *time.Time
+	// 最近一次使用的 TOTP 步数，同一验证码不能重复使用
+	TOTPLastStep int64 `gorm:"not null;default:0"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
+
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) MFAEnabled() bool {
+	return u.MFAEnabledAt != nil
+}
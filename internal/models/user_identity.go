@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// 第三方登录身份（GitHub、OIDC 等）与本站用户的绑定
+type UserIdentity struct {
+	ID uint `gorm:"primaryKey"`
+
+	UserID   uint   `gorm:"not null;index"`
+	Provider string `gorm:"size:32;not null;uniqueIndex:uk_user_identities_provider_subject,priority:1"`
+	Subject  string `gorm:"size:255;not null;uniqueIndex:uk_user_identities_provider_subject,priority:2"`
+	// 绑定时提供方返回的邮箱，仅供展示
+	Email       string `gorm:"size:255;not null;default:''"`
+	LastLoginAt *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,95 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type GitHubConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+
+	// 以下默认指向 github.com，GitHub Enterprise 或测试时可覆盖
+	AuthURL  string
+	TokenURL string
+	APIURL   string
+
+	HTTPClient *http.Client
+}
+
+// GitHub 预设：纯 OAuth2（没有 id_token），身份通过 REST API 获取
+type GitHubProvider struct {
+	cfg    GitHubConfig
+	client *http.Client
+}
+
+func NewGitHub(c GitHubConfig) (*GitHubProvider, error) {
+	if c.ClientID == "" || c.RedirectURL == "" {
+		return nil, errors.New("github: client id and redirect url are required")
+	}
+	if c.AuthURL == "" {
+		c.AuthURL = "https://github.com/login/oauth/authorize"
+	}
+	if c.TokenURL == "" {
+		c.TokenURL = "https://github.com/login/oauth/access_token"
+	}
+	if c.APIURL == "" {
+		c.APIURL = "https://api.github.com"
+	}
+	c.APIURL = strings.TrimRight(c.APIURL, "/")
+	return &GitHubProvider{cfg: c, client: defaultClient(c.HTTPClient)}, nil
+}
+
+func (p *GitHubProvider) Name() string { return "github" }
+
+// GitHub 不支持 nonce（没有 id_token），state + PKCE 已足够防 CSRF 与授权码截获
+func (p *GitHubProvider) AuthCodeURL(_ context.Context, state, _ string, challenge string) (string, error) {
+	return authCodeURL(p.cfg.AuthURL, p.cfg.ClientID, p.cfg.RedirectURL, []string{"read:user", "user:email"},
+		state, challenge, nil)
+}
+
+func (p *GitHubProvider) Identify(ctx context.Context, code, verifier, _ string) (*Identity, error) {
+	tr, err := exchange(ctx, p.client, p.cfg.TokenURL, p.cfg.ClientID, p.cfg.ClientSecret, p.cfg.RedirectURL, code, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, p.client, p.cfg.APIURL+"/user", tr.AccessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errors.New("github: missing user id")
+	}
+
+	// 公开资料里的邮箱未必已验证，取 /user/emails 中已验证的主邮箱
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, p.client, p.cfg.APIURL+"/user/emails", tr.AccessToken, &emails); err != nil {
+		return nil, err
+	}
+	id := &Identity{
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Name:     user.Name,
+		Username: user.Login,
+	}
+	for _, e := range emails {
+		if e.Primary {
+			id.Email = e.Email
+			id.EmailVerified = e.Verified
+			break
+		}
+	}
+	return id, nil
+}
@@ -0,0 +1,164 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrExchange = errors.New("oauth: code exchange failed")
+	ErrIDToken  = errors.New("oauth: invalid id_token")
+)
+
+// 第三方登录提供方：授权码模式 + PKCE
+type Provider interface {
+	// 路由中的名字，如 github、oidc
+	Name() string
+	// 授权跳转地址；challenge 为 PKCE S256 challenge
+	AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error)
+	// 用授权码换取令牌并取得用户身份；nonce 用于校验 id_token
+	Identify(ctx context.Context, code, verifier, nonce string) (*Identity, error)
+}
+
+// 外部身份
+type Identity struct {
+	Subject       string // 提供方内的唯一用户 ID
+	Email         string
+	EmailVerified bool
+	Name          string
+	Username      string // 提供方的登录名（GitHub login / preferred_username）
+}
+
+// 生成 PKCE code_verifier（43 字符）与对应的 S256 challenge
+func NewPKCE() (verifier, challenge string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(b)
+	return verifier, Challenge(verifier), nil
+}
+
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// 拼接授权地址
+func authCodeURL(endpoint, clientID, redirectURL string, scopes []string, state, challenge string, extra url.Values) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", clientID)
+	q.Set("redirect_uri", redirectURL)
+	q.Set("scope", strings.Join(scopes, " "))
+	q.Set("state", state)
+	q.Set("code_challenge", challenge)
+	q.Set("code_challenge_method", "S256")
+	for k, v := range extra {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// 授权码换令牌（client_secret_post）
+func exchange(ctx context.Context, client *http.Client, tokenURL, clientID, clientSecret, redirectURL, code, verifier string) (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURL)
+	form.Set("client_id", clientID)
+	form.Set("code_verifier", verifier)
+	if clientSecret != "" {
+		form.Set("client_secret", clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// GitHub 默认返回表单编码，需显式要求 JSON
+	req.Header.Set("Accept", "application/json")
+
+	var tr tokenResponse
+	status, err := doJSON(client, req, &tr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	// GitHub 出错时也返回 200，只能看 error 字段
+	if tr.Error != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrExchange, tr.Error, tr.ErrorDescription)
+	}
+	if status != http.StatusOK || tr.AccessToken == "" {
+		return nil, fmt.Errorf("%w: status %d", ErrExchange, status)
+	}
+	return &tr, nil
+}
+
+// 带 Bearer 令牌的 GET 请求
+func getJSON(ctx context.Context, client *http.Client, endpoint, accessToken string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	status, err := doJSON(client, req, out)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("oauth: GET %s: status %d", endpoint, status)
+	}
+	return nil
+}
+
+// 执行请求并解析 JSON；非 2xx 时只返回状态码（错误体已尽量解析进 out）
+func doJSON(client *http.Client, req *http.Request, out any) (int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, out); err != nil && resp.StatusCode/100 == 2 {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
+
+func defaultClient(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
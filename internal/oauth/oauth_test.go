@@ -0,0 +1,190 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	jwtutil "blog-service/internal/utils/jwt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 本地模拟的 OIDC 服务：discovery、JWKS、授权码换令牌（校验 PKCE）
+type mockOIDC struct {
+	*httptest.Server
+	key      *jwtutil.Key
+	clientID string
+
+	// 下一次签发 id_token 用的 nonce、授权码对应的 challenge
+	nonce     string
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockOIDC(t *testing.T) *mockOIDC {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa key: %v", err)
+	}
+	k, err := jwtutil.NewKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	k.Private = priv
+	m := &mockOIDC{key: k, clientID: "blog"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwtutil.JWKSet{Keys: []jwtutil.JWK{k.JWK()}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "good-code" || Challenge(r.Form.Get("code_verifier")) != m.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		claims := jwt.MapClaims{
+			"iss":            m.URL,
+			"aud":            m.clientID,
+			"sub":            "user-42",
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"iat":            time.Now().Unix(),
+			"nonce":          m.nonce,
+			"email":          "alice@example.com",
+			"email_verified": true,
+			"name":           "Alice",
+		}
+		for k, v := range m.claims {
+			claims[k] = v
+		}
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		tok.Header["kid"] = k.ID
+		signed, _ := tok.SignedString(priv)
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "at",
+			"token_type":   "Bearer",
+			"id_token":     signed,
+		})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func TestOIDCFlow(t *testing.T) {
+	m := newMockOIDC(t)
+	p, err := NewOIDC(OIDCConfig{Issuer: m.URL, ClientID: m.clientID, RedirectURL: "http://app/cb"})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	ctx := context.Background()
+
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatalf("pkce: %v", err)
+	}
+	raw, err := p.AuthCodeURL(ctx, "st", "n-1", challenge)
+	if err != nil {
+		t.Fatalf("auth url: %v", err)
+	}
+	u, _ := url.Parse(raw)
+	q := u.Query()
+	if !strings.HasPrefix(raw, m.URL+"/authorize?") || q.Get("state") != "st" || q.Get("nonce") != "n-1" ||
+		q.Get("code_challenge") != challenge || q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected auth url: %s", raw)
+	}
+
+	m.nonce, m.challenge = "n-1", challenge
+	id, err := p.Identify(ctx, "good-code", verifier, "n-1")
+	if err != nil {
+		t.Fatalf("identify: %v", err)
+	}
+	if id.Subject != "user-42" || id.Email != "alice@example.com" || !id.EmailVerified || id.Name != "Alice" {
+		t.Fatalf("unexpected identity: %+v", id)
+	}
+
+	// 错误的 verifier：换令牌失败
+	if _, err := p.Identify(ctx, "good-code", "wrong-verifier", "n-1"); !errors.Is(err, ErrExchange) {
+		t.Fatalf("want ErrExchange, got %v", err)
+	}
+	// nonce 不一致：id_token 被拒
+	if _, err := p.Identify(ctx, "good-code", verifier, "other"); !errors.Is(err, ErrIDToken) {
+		t.Fatalf("want ErrIDToken for nonce, got %v", err)
+	}
+	// aud 不是自己
+	m.claims = jwt.MapClaims{"aud": "someone-else"}
+	if _, err := p.Identify(ctx, "good-code", verifier, "n-1"); !errors.Is(err, ErrIDToken) {
+		t.Fatalf("want ErrIDToken for aud, got %v", err)
+	}
+	// 已过期
+	m.claims = jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}
+	if _, err := p.Identify(ctx, "good-code", verifier, "n-1"); !errors.Is(err, ErrIDToken) {
+		t.Fatalf("want ErrIDToken for exp, got %v", err)
+	}
+}
+
+func TestGitHubFlow(t *testing.T) {
+	var challenge string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		// GitHub 出错时同样返回 200
+		if Challenge(r.Form.Get("code_verifier")) != challenge || r.Form.Get("client_secret") != "secret" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "gho_x", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/api/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gho_x" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id":1001,"login":"octocat","name":"The Octocat"}`))
+	})
+	mux.HandleFunc("/api/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"email":"old@example.com","primary":false,"verified":true},{"email":"octo@example.com","primary":true,"verified":true}]`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	p, err := NewGitHub(GitHubConfig{
+		ClientID: "cid", ClientSecret: "secret", RedirectURL: "http://app/cb",
+		AuthURL: srv.URL + "/login/oauth/authorize", TokenURL: srv.URL + "/login/oauth/access_token", APIURL: srv.URL + "/api",
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	verifier, ch, _ := NewPKCE()
+	challenge = ch
+
+	id, err := p.Identify(context.Background(), "code", verifier, "")
+	if err != nil {
+		t.Fatalf("identify: %v", err)
+	}
+	if id.Subject != "1001" || id.Username != "octocat" || id.Email != "octo@example.com" || !id.EmailVerified {
+		t.Fatalf("unexpected identity: %+v", id)
+	}
+	if _, err := p.Identify(context.Background(), "code", "wrong", ""); !errors.Is(err, ErrExchange) {
+		t.Fatalf("want ErrExchange, got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	jwtutil "blog-service/internal/utils/jwt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type OIDCConfig struct {
+	Name         string // 默认 "oidc"
+	Issuer       string // 通过 {Issuer}/.well-known/openid-configuration 发现端点
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // 默认 openid email profile
+
+	HTTPClient *http.Client
+}
+
+// 通用 OIDC 提供方（Keycloak、Google、Auth0 等）
+type OIDCProvider struct {
+	cfg    OIDCConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	meta      *oidcMetadata
+	keys      map[string]jwtutil.JWK
+	keysFetch time.Time
+}
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// 密钥轮换时按 kid 找不到会重新拉取 JWKS，但间隔不少于该值
+const jwksMinRefresh = time.Minute
+
+func NewOIDC(c OIDCConfig) (*OIDCProvider, error) {
+	if c.Issuer == "" || c.ClientID == "" || c.RedirectURL == "" {
+		return nil, errors.New("oidc: issuer, client id and redirect url are required")
+	}
+	if c.Name == "" {
+		c.Name = "oidc"
+	}
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{"openid", "email", "profile"}
+	}
+	c.Issuer = strings.TrimRight(c.Issuer, "/")
+	return &OIDCProvider{cfg: c, client: defaultClient(c.HTTPClient)}, nil
+}
+
+func (p *OIDCProvider) Name() string { return p.cfg.Name }
+
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	return authCodeURL(meta.AuthorizationEndpoint, p.cfg.ClientID, p.cfg.RedirectURL, p.cfg.Scopes,
+		state, challenge, url.Values{"nonce": {nonce}})
+}
+
+func (p *OIDCProvider) Identify(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := exchange(ctx, p.client, meta.TokenEndpoint, p.cfg.ClientID, p.cfg.ClientSecret, p.cfg.RedirectURL, code, verifier)
+	if err != nil {
+		return nil, err
+	}
+	if tr.IDToken == "" {
+		return nil, fmt.Errorf("%w: missing id_token", ErrIDToken)
+	}
+	claims, err := p.verifyIDToken(ctx, meta, tr.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	id := claims.identity()
+	// id_token 不带邮箱时（部分提供方只在 userinfo 中返回）再查一次
+	if id.Email == "" && meta.UserinfoEndpoint != "" {
+		var info idTokenClaims
+		if err := getJSON(ctx, p.client, meta.UserinfoEndpoint, tr.AccessToken, &info); err != nil {
+			return nil, err
+		}
+		if info.Subject == claims.Subject {
+			id.Email = info.Email
+			id.EmailVerified = bool(info.EmailVerified)
+			if id.Name == "" {
+				id.Name = info.Name
+			}
+			if id.Username == "" {
+				id.Username = info.PreferredUsername
+			}
+		}
+	}
+	return id, nil
+}
+
+type idTokenClaims struct {
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     flexBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	AuthorizedParty   string   `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+func (c *idTokenClaims) identity() *Identity {
+	return &Identity{
+		Subject:       c.Subject,
+		Email:         c.Email,
+		EmailVerified: bool(c.EmailVerified),
+		Name:          c.Name,
+		Username:      c.PreferredUsername,
+	}
+}
+
+// 有些提供方把 email_verified 返回成字符串 "true"
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	*b = flexBool(s == "true")
+	return nil
+}
+
+// 校验签名（JWKS）、iss、aud、exp 与 nonce
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, meta *oidcMetadata, raw, nonce string) (*idTokenClaims, error) {
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(raw, &claims,
+		func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			pub, err := p.key(ctx, meta, kid)
+			if err != nil {
+				return nil, err
+			}
+			if !methodMatchesKey(t.Method, pub) {
+				return nil, errors.New("alg does not match key type")
+			}
+			return pub, nil
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIDToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrIDToken)
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrIDToken)
+	}
+	// 多个 aud 时 azp 必须是自己
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: azp mismatch", ErrIDToken)
+	}
+	return &claims, nil
+}
+
+func methodMatchesKey(m jwt.SigningMethod, pub any) bool {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		_, ok := m.(*jwt.SigningMethodRSA)
+		return ok
+	case *ecdsa.PublicKey:
+		_, ok := m.(*jwt.SigningMethodECDSA)
+		return ok
+	case ed25519.PublicKey:
+		return m == jwt.SigningMethodEdDSA
+	}
+	return false
+}
+
+// discovery 结果缓存在内存中；启动时不请求，首次使用时再拉取，失败下次重试
+func (p *OIDCProvider) metadata(ctx context.Context) (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var meta oidcMetadata
+	if err := getJSON(ctx, p.client, p.cfg.Issuer+"/.well-known/openid-configuration", "", &meta); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimRight(meta.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer mismatch %q", meta.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: missing endpoints")
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// 按 kid 取公钥；未命中且距上次拉取超过 jwksMinRefresh 时刷新
+func (p *OIDCProvider) key(ctx context.Context, meta *oidcMetadata, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if j, ok := p.lookupKey(kid); ok {
+		return j.PublicKey()
+	}
+	if !p.keysFetch.IsZero() && time.Since(p.keysFetch) < jwksMinRefresh {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+
+	var set jwtutil.JWKSet
+	if err := getJSON(ctx, p.client, meta.JWKSURI, "", &set); err != nil {
+		return nil, err
+	}
+	p.keys = make(map[string]jwtutil.JWK, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use == "" || k.Use == "sig" {
+			p.keys[k.Kid] = k
+		}
+	}
+	p.keysFetch = time.Now()
+
+	if j, ok := p.lookupKey(kid); ok {
+		return j.PublicKey()
+	}
+	return nil, fmt.Errorf("unknown kid %q", kid)
+}
+
+// token 不带 kid 时，只有唯一一把密钥才可用
+func (p *OIDCProvider) lookupKey(kid string) (jwtutil.JWK, bool) {
+	if j, ok := p.keys[kid]; ok {
+		return j, true
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, j := range p.keys {
+			return j, true
+		}
+	}
+	return jwtutil.JWK{}, false
+}
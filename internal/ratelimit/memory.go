@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 进程内令牌桶；多实例部署时各实例独立计数
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+
+	now func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// 桶补满所需时间，超过即可回收
+	idle time.Duration
+}
+
+func NewMemory() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+func (s *MemoryStore) Allow(_ context.Context, key string, rule Rule) (Result, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 定期清理已补满的桶，防止 key 无限增长
+	if s.calls++; s.calls%1024 == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rule.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.idle = time.Duration(float64(rule.Burst) / rule.Rate * float64(time.Second))
+
+	var res Result
+	b.tokens, res = take(b.tokens, b.last, now, rule)
+	b.last = now
+	return res, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for k, b := range s.buckets {
+		if now.Sub(b.last) > b.idle {
+			delete(s.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// 令牌桶规则：每秒补充 Rate 个令牌，桶容量 Burst
+type Rule struct {
+	Rate  float64
+	Burst int
+}
+
+// 每分钟 n 次，允许突发 burst 次
+func PerMinute(n, burst int) Rule {
+	return Rule{Rate: float64(n) / 60, Burst: burst}
+}
+
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // 被拒绝时，距离下一个令牌可用的时间
+}
+
+// 限流存储：单实例用内存，多实例共享用 Redis
+type Store interface {
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+}
+
+// 按令牌桶计算：返回新的令牌数与结果
+func take(tokens float64, last, now time.Time, rule Rule) (float64, Result) {
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens += elapsed * rule.Rate
+	}
+	if burst := float64(rule.Burst); tokens > burst {
+		tokens = burst
+	}
+	if tokens >= 1 {
+		tokens--
+		return tokens, Result{Allowed: true, Remaining: int(tokens)}
+	}
+	wait := time.Duration((1 - tokens) / rule.Rate * float64(time.Second))
+	return tokens, Result{Allowed: false, RetryAfter: wait}
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryTokenBucket(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewMemory()
+	s.now = func() time.Time { return now }
+	rule := Rule{Rate: 1, Burst: 2}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if res, _ := s.Allow(ctx, "k", rule); !res.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	res, _ := s.Allow(ctx, "k", rule)
+	if res.Allowed {
+		t.Fatal("third request should be limited")
+	}
+	if res.RetryAfter != time.Second {
+		t.Fatalf("retry after want 1s got %s", res.RetryAfter)
+	}
+
+	// 其它 key 不受影响
+	if res, _ := s.Allow(ctx, "other", rule); !res.Allowed {
+		t.Fatal("other key should be allowed")
+	}
+
+	now = now.Add(time.Second)
+	if res, _ := s.Allow(ctx, "k", rule); !res.Allowed {
+		t.Fatal("token should be refilled after 1s")
+	}
+}
+
+// 假的 RESP 服务端：校验 EVAL 请求格式并返回固定结果
+func TestRedisStoreProtocol(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	got := make(chan []string, 4)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		r := bufio.NewReader(c)
+		for {
+			v, err := readReply(r)
+			if err != nil {
+				return
+			}
+			args := make([]string, 0)
+			for _, a := range v.([]any) {
+				args = append(args, a.(string))
+			}
+			got <- args
+			switch args[0] {
+			case "AUTH":
+				c.Write([]byte("+OK\r\n"))
+			case "EVAL":
+				c.Write([]byte("*3\r\n:0\r\n:1500\r\n:0\r\n"))
+			}
+		}
+	}()
+
+	s := NewRedis(RedisConfig{Addr: ln.Addr().String(), Password: "secret"})
+	res, err := s.Allow(context.Background(), "login:1.2.3.4", Rule{Rate: 0.5, Burst: 3})
+	if err != nil {
+		t.Fatalf("allow err: %v", err)
+	}
+	if res.Allowed || res.RetryAfter != 1500*time.Millisecond {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	if auth := <-got; auth[0] != "AUTH" || auth[1] != "secret" {
+		t.Fatalf("expected AUTH first, got %v", auth)
+	}
+	eval := <-got
+	if eval[0] != "EVAL" || !strings.Contains(eval[1], "HMGET") || eval[2] != "1" ||
+		eval[3] != "ratelimit:login:1.2.3.4" || eval[4] != "0.5" || eval[5] != "3" {
+		t.Fatalf("unexpected EVAL args %v", eval)
+	}
+}
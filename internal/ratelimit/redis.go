@@ -0,0 +1,235 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// 令牌桶脚本：在 Redis 端原子执行，多实例共享计数
+// KEYS[1]=桶 key；ARGV: rate(每秒)、burst、now(毫秒)
+// 返回 {allowed, retry_after_ms, remaining}
+const tokenBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local data = redis.call('HMGET', KEYS[1], 't', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil then
+  tokens = burst
+  ts = now
+end
+local elapsed = math.max(0, now - ts) / 1000
+tokens = math.min(burst, tokens + elapsed * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) / rate * 1000)
+end
+redis.call('HSET', KEYS[1], 't', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, retry, math.floor(tokens)}
+`
+
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+	Prefix   string // key 前缀，默认 "ratelimit:"
+}
+
+// Redis 兼容存储（Redis / KeyDB / Valkey 等），直接走 RESP 协议，不依赖客户端库
+type RedisStore struct {
+	Addr     string
+	Password string
+	DB       int
+	Prefix   string
+	Timeout  time.Duration
+
+	pool chan *redisConn
+}
+
+func NewRedis(c RedisConfig) *RedisStore {
+	prefix := c.Prefix
+	if prefix == "" {
+		prefix = "ratelimit:"
+	}
+	return &RedisStore{
+		Addr:     c.Addr,
+		Password: c.Password,
+		DB:       c.DB,
+		Prefix:   prefix,
+		Timeout:  time.Second,
+		pool:     make(chan *redisConn, 16),
+	}
+}
+
+func (s *RedisStore) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	reply, err := s.do(ctx,
+		"EVAL", tokenBucketScript, "1", s.Prefix+key,
+		strconv.FormatFloat(rule.Rate, 'f', -1, 64),
+		strconv.Itoa(rule.Burst),
+		strconv.FormatInt(time.Now().UnixMilli(), 10),
+	)
+	if err != nil {
+		return Result{}, err
+	}
+	arr, ok := reply.([]any)
+	if !ok || len(arr) != 3 {
+		return Result{}, fmt.Errorf("ratelimit redis: unexpected reply %v", reply)
+	}
+	allowed, _ := arr[0].(int64)
+	retry, _ := arr[1].(int64)
+	remaining, _ := arr[2].(int64)
+	return Result{
+		Allowed:    allowed == 1,
+		Remaining:  int(remaining),
+		RetryAfter: time.Duration(retry) * time.Millisecond,
+	}, nil
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// 执行一条命令；出错的连接直接关闭，不放回池中
+func (s *RedisStore) do(ctx context.Context, args ...string) (any, error) {
+	cn, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(s.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	cn.SetDeadline(deadline)
+
+	reply, err := cn.command(args...)
+	if err != nil {
+		cn.Close()
+		return nil, err
+	}
+	s.put(cn)
+	return reply, nil
+}
+
+func (s *RedisStore) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case cn := <-s.pool:
+		return cn, nil
+	default:
+	}
+
+	d := net.Dialer{Timeout: s.Timeout}
+	c, err := d.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return nil, err
+	}
+	cn := &redisConn{Conn: c, r: bufio.NewReader(c)}
+	cn.SetDeadline(time.Now().Add(s.Timeout))
+	if s.Password != "" {
+		if _, err := cn.command("AUTH", s.Password); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	if s.DB != 0 {
+		if _, err := cn.command("SELECT", strconv.Itoa(s.DB)); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+func (s *RedisStore) put(cn *redisConn) {
+	select {
+	case s.pool <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+type redisError string
+
+func (e redisError) Error() string { return "ratelimit redis: " + string(e) }
+
+func (cn *redisConn) command(args ...string) (any, error) {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, a := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(a)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, a...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := cn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readReply(cn.r)
+}
+
+// 解析 RESP2 回复：+simple、-error、:integer、$bulk、*array
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("ratelimit redis: malformed reply %q", line)
+	}
+	body := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return string(b[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		arr := make([]any, 0, n)
+		for i := 0; i < n; i++ {
+			v, err := readReply(r)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("ratelimit redis: unknown reply type %q", line[0])
+	}
+}
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"time"
+
+	"blog-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type CategoryRepo struct {
+	DB *gorm.DB
+}
+
+func NewCategoryRepo(db *gorm.DB) *CategoryRepo {
+	return &CategoryRepo{DB: db}
+}
+
+func (r *CategoryRepo) Create(c *models.Category) error {
+	return r.DB.Create(c).Error
+}
+
+func (r *CategoryRepo) Update(c *models.Category) error {
+	return r.DB.Omit("Parent").Save(c).Error
+}
+
+func (r *CategoryRepo) DeleteByID(id uint) error {
+	return r.DB.Delete(&models.Category{}, id).Error
+}
+
+func (r *CategoryRepo) FindByID(id uint) (*models.Category, error) {
+	var c models.Category
+	if err := r.DB.First(&c, id).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *CategoryRepo) FindBySlug(slug string) (*models.Category, error) {
+	var c models.Category
+	if err := r.DB.Where("slug = ?", slug).First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *CategoryRepo) SlugExists(slug string) (bool, error) {
+	var cnt int64
+	if err := r.DB.Model(&models.Category{}).Where("slug = ?", slug).Count(&cnt).Error; err != nil {
+		return false, err
+	}
+	return cnt > 0, nil
+}
+
+// 分类数量很少，整表读出后在内存里组装树
+func (r *CategoryRepo) ListAll() ([]models.Category, error) {
+	var items []models.Category
+	err := r.DB.Order("sort_order ASC, id ASC").Find(&items).Error
+	return items, err
+}
+
+func (r *CategoryRepo) CountChildren(id uint) (int64, error) {
+	var cnt int64
+	err := r.DB.Model(&models.Category{}).Where("parent_id = ?", id).Count(&cnt).Error
+	return cnt, err
+}
+
+// 各分类（直接挂载的）已发布文章数
+func (r *CategoryRepo) PublishedPostCounts() (map[uint]int64, error) {
+	var rows []struct {
+		CategoryID uint
+		Cnt        int64
+	}
+	if err := r.DB.Model(&models.Post{}).
+		Select("category_id, COUNT(*) AS cnt").
+		Where("status = ? AND category_id IS NOT NULL", models.PostPublished).
+		Group("category_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		out[row.CategoryID] = row.Cnt
+	}
+	return out, nil
+}
+
+type CategoryLastMod struct {
+	Slug    string
+	LastMod time.Time
+}
+
+// sitemap：直接挂有已发布文章的分类，及其下文章最近的更新时间
+func (r *CategoryRepo) ListSitemap() ([]CategoryLastMod, error) {
+	var items []CategoryLastMod
+	err := r.DB.Table("categories").
+		Select("categories.slug, MAX(posts.updated_at) AS last_mod").
+		Joins("JOIN posts ON posts.category_id = categories.id AND posts.status = ?", models.PostPublished).
+		Group("categories.id, categories.slug").
+		Order("categories.slug ASC").
+		Scan(&items).Error
+	return items, err
+}
@@ -0,0 +1,192 @@
+package repositories
+
+import (
+	"time"
+
+	"blog-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CommentRepo struct {
+	DB *gorm.DB
+}
+
+func NewCommentRepo(db *gorm.DB) *CommentRepo {
+	return &CommentRepo{DB: db}
+}
+
+// 创建评论；已通过的评论同步 +1 文章评论数（同一事务）
+func (r *CommentRepo) Create(cm *models.Comment) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(cm).Error; err != nil {
+			return err
+		}
+		if cm.Status == models.CommentApproved {
+			return incCommentCount(tx, cm.PostID, 1)
+		}
+		return nil
+	})
+}
+
+func (r *CommentRepo) FindByID(id uint) (*models.Comment, error) {
+	var cm models.Comment
+	if err := r.DB.Preload("Author").
+		Where("deleted_at IS NULL").
+		First(&cm, id).Error; err != nil {
+		return nil, err
+	}
+	return &cm, nil
+}
+
+// 列出文章下全部已通过的评论（包含已软删除的，用于保留楼层结构），按时间正序
+func (r *CommentRepo) ListApprovedByPost(postID uint) ([]models.Comment, error) {
+	var items []models.Comment
+	err := r.DB.Preload("Author").
+		Where("post_id = ? AND status = ?", postID, models.CommentApproved).
+		Order("created_at ASC, id ASC").
+		Find(&items).Error
+	return items, err
+}
+
+// 更新评论内容与状态；状态离开/进入 approved 时同步文章评论数
+func (r *CommentRepo) UpdateContent(cm *models.Comment, content string, status models.CommentStatus) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Comment{}).
+			Where("id = ? AND deleted_at IS NULL", cm.ID).
+			Updates(map[string]any{"content": content, "status": status})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 || cm.Status == status {
+			return nil
+		}
+		if cm.Status == models.CommentApproved {
+			return incCommentCount(tx, cm.PostID, -1)
+		}
+		if status == models.CommentApproved {
+			return incCommentCount(tx, cm.PostID, 1)
+		}
+		return nil
+	})
+}
+
+// 软删除评论；已通过的评论同步 -1 文章评论数
+func (r *CommentRepo) SoftDelete(cm *models.Comment) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Comment{}).
+			Where("id = ? AND deleted_at IS NULL", cm.ID).
+			Update("deleted_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		// 并发重复删除时不重复扣减
+		if res.RowsAffected == 0 || cm.Status != models.CommentApproved {
+			return nil
+		}
+		return incCommentCount(tx, cm.PostID, -1)
+	})
+}
+
+func incCommentCount(tx *gorm.DB, postID uint, delta int) error {
+	q := tx.Model(&models.Post{}).Where("id = ?", postID)
+	if delta < 0 {
+		// 防止 unsigned 下溢
+		return q.Where("comment_count >= ?", -delta).
+			UpdateColumn("comment_count", gorm.Expr("comment_count - ?", -delta)).Error
+	}
+	return q.UpdateColumn("comment_count", gorm.Expr("comment_count + ?", delta)).Error
+}
+
+type CommentFilter struct {
+	Status   *models.CommentStatus
+	PostID   uint
+	AuthorID uint
+}
+
+// 管理后台：跨文章分页查询评论（不含已删除）
+func (r *CommentRepo) ListForModeration(f CommentFilter, page, size int) ([]models.Comment, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size <= 0 || size > 100 {
+		size = 20
+	}
+	offset := (page - 1) * size
+
+	q := r.DB.Model(&models.Comment{}).Where("deleted_at IS NULL")
+	if f.Status != nil {
+		q = q.Where("status = ?", *f.Status)
+	}
+	if f.PostID != 0 {
+		q = q.Where("post_id = ?", f.PostID)
+	}
+	if f.AuthorID != 0 {
+		q = q.Where("author_id = ?", f.AuthorID)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var items []models.Comment
+	err := q.
+		Preload("Author").
+		Preload("Post", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id,title,slug")
+		}).
+		Order("created_at ASC, id ASC").
+		Offset(offset).Limit(size).
+		Find(&items).Error
+	return items, total, err
+}
+
+// 批量修改评论状态，返回实际变更条数；
+// 只有可见性发生变化（进入/离开 approved）时才调整对应文章的评论数
+func (r *CommentRepo) BulkUpdateStatus(ids []uint, status models.CommentStatus) (int64, error) {
+	var changed int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var items []models.Comment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id,post_id,status").
+			Where("id IN ? AND deleted_at IS NULL AND status <> ?", ids, status).
+			Find(&items).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		deltas := map[uint]int{}
+		changedIDs := make([]uint, 0, len(items))
+		for _, cm := range items {
+			changedIDs = append(changedIDs, cm.ID)
+			if cm.Status == models.CommentApproved {
+				deltas[cm.PostID]--
+			} else if status == models.CommentApproved {
+				deltas[cm.PostID]++
+			}
+		}
+
+		res := tx.Model(&models.Comment{}).
+			Where("id IN ?", changedIDs).
+			Update("status", status)
+		if res.Error != nil {
+			return res.Error
+		}
+		changed = res.RowsAffected
+
+		for postID, d := range deltas {
+			if d == 0 {
+				continue
+			}
+			if err := incCommentCount(tx, postID, d); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return changed, err
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"time"
+
+	"blog-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type IdentityRepo struct {
+	DB *gorm.DB
+}
+
+func NewIdentityRepo(db *gorm.DB) *IdentityRepo {
+	return &IdentityRepo{DB: db}
+}
+
+func (r *IdentityRepo) FindByProviderSubject(provider, subject string) (*models.UserIdentity, error) {
+	var id models.UserIdentity
+	if err := r.DB.Where("provider = ? AND subject = ?", provider, subject).First(&id).Error; err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (r *IdentityRepo) ListByUser(userID uint) ([]models.UserIdentity, error) {
+	var items []models.UserIdentity
+	err := r.DB.Where("user_id = ?", userID).Order("id ASC").Find(&items).Error
+	return items, err
+}
+
+func (r *IdentityRepo) Create(id *models.UserIdentity) error {
+	return r.DB.Create(id).Error
+}
+
+// 首次第三方登录：同一事务内创建用户与绑定
+func (r *IdentityRepo) CreateWithUser(u *models.User, id *models.UserIdentity) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(u).Error; err != nil {
+			return err
+		}
+		id.UserID = u.ID
+		return tx.Create(id).Error
+	})
+}
+
+func (r *IdentityRepo) TouchLogin(id uint, t time.Time) error {
+	return r.DB.Model(&models.UserIdentity{}).Where("id = ?", id).Update("last_login_at", t).Error
+}
+
+// 解绑；不属于该用户时返回 gorm.ErrRecordNotFound
+func (r *IdentityRepo) Delete(userID, id uint) error {
+	res := r.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.UserIdentity{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
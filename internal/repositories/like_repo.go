@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"blog-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LikeRepo struct {
+	DB *gorm.DB
+}
+
+func NewLikeRepo(db *gorm.DB) *LikeRepo {
+	return &LikeRepo{DB: db}
+}
+
+// 点赞：依赖 uk_post_likes_post_user 保证幂等，重复点赞视为成功；
+// 只有真正插入时才 +1 文章点赞数（同一事务）
+func (r *LikeRepo) Like(postID, userID uint) (bool, error) {
+	created := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.PostLike{PostID: postID, UserID: userID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+		return tx.Model(&models.Post{}).
+			Where("id = ?", postID).
+			UpdateColumn("like_count", gorm.Expr("like_count + 1")).Error
+	})
+	return created, err
+}
+
+// 取消点赞：未点赞时也视为成功
+func (r *LikeRepo) Unlike(postID, userID uint) (bool, error) {
+	removed := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("post_id = ? AND user_id = ?", postID, userID).
+			Delete(&models.PostLike{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		removed = true
+		return tx.Model(&models.Post{}).
+			Where("id = ?", postID).
+			UpdateColumn("like_count", gorm.Expr("GREATEST(like_count, 1) - 1")).Error
+	})
+	return removed, err
+}
+
+func (r *LikeRepo) IsLiked(postID, userID uint) (bool, error) {
+	var cnt int64
+	if err := r.DB.Model(&models.PostLike{}).
+		Where("post_id = ? AND user_id = ?", postID, userID).
+		Count(&cnt).Error; err != nil {
+		return false, err
+	}
+	return cnt > 0, nil
+}
+
+// 我点赞过的（已发布）文章，按点赞时间倒序
+func (r *LikeRepo) ListLikedPosts(userID uint, page, size int) ([]models.Post, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size <= 0 || size > 50 {
+		size = 10
+	}
+	offset := (page - 1) * size
+
+	q := r.DB.Model(&models.Post{}).
+		Joins("JOIN post_likes ON post_likes.post_id = posts.id").
+		Where("post_likes.user_id = ? AND posts.status = ?", userID, models.PostPublished)
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var items []models.Post
+	err := q.
+		Select("posts.id,posts.title,posts.slug,posts.status,posts.published_at,posts.author_id,posts.category_id,posts.view_count,posts.like_count,posts.comment_count,posts.created_at,posts.updated_at").
+		Preload("Tags").
+		Preload("Author").
+		Preload("Category").
+		Order("post_likes.created_at DESC").
+		Offset(offset).Limit(size).
+		Find(&items).Error
+	return items, total, err
+}
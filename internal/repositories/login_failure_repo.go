@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"time"
+
+	"blog-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginFailureRepo struct {
+	DB *gorm.DB
+}
+
+func NewLoginFailureRepo(db *gorm.DB) *LoginFailureRepo {
+	return &LoginFailureRepo{DB: db}
+}
+
+// 当前锁定截止时间；没有记录或未锁定时返回 nil
+func (r *LoginFailureRepo) LockedUntil(userID uint, ip string) (*time.Time, error) {
+	var f models.LoginFailure
+	err := r.DB.Select("locked_until").
+		Where("user_id = ? AND ip = ?", userID, ip).
+		Take(&f).Error
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	return f.LockedUntil, err
+}
+
+// 失败次数 +1，返回累计次数（原子自增，避免并发尝试漏计）
+func (r *LoginFailureRepo) Inc(userID uint, ip string, now time.Time) (uint, error) {
+	var n uint
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]any{
+				"failures":   gorm.Expr("failures + 1"),
+				"updated_at": now,
+			}),
+		}).Create(&models.LoginFailure{UserID: userID, IP: ip, Failures: 1, UpdatedAt: now}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.LoginFailure{}).
+			Where("user_id = ? AND ip = ?", userID, ip).
+			Pluck("failures", &n).Error
+	})
+	return n, err
+}
+
+func (r *LoginFailureRepo) SetLockedUntil(userID uint, ip string, until time.Time) error {
+	return r.DB.Model(&models.LoginFailure{}).
+		Where("user_id = ? AND ip = ?", userID, ip).
+		Update("locked_until", until).Error
+}
+
+// 登录成功：清零该 IP 与账号整体的失败计数
+func (r *LoginFailureRepo) Clear(userID uint, ip string) error {
+	return r.DB.Where("user_id = ? AND ip IN ?", userID, []string{ip, models.LoginFailureAnyIP}).
+		Delete(&models.LoginFailure{}).Error
+}
+
+// 重置密码：清除该账号在所有 IP 上的失败记录
+func (r *LoginFailureRepo) ClearUser(userID uint) error {
+	return r.DB.Where("user_id = ?", userID).
+		Delete(&models.LoginFailure{}).Error
+}
+
+// 清理 before 之前就不再活跃且已解锁的记录
+func (r *LoginFailureRepo) PurgeStale(before time.Time) (int64, error) {
+	res := r.DB.Where("updated_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, before).
+		Delete(&models.LoginFailure{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"blog-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MediaRepo struct {
+	DB *gorm.DB
+}
+
+func NewMediaRepo(db *gorm.DB) *MediaRepo {
+	return &MediaRepo{DB: db}
+}
+
+// 按 hash 唯一键插入；已存在（并发上传同一文件）时不插入，返回 false
+func (r *MediaRepo) Create(m *models.Media) (bool, error) {
+	res := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(m)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *MediaRepo) FindByHash(hash string) (*models.Media, error) {
+	var m models.Media
+	if err := r.DB.Where("hash = ?", hash).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
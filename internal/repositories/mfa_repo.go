@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"time"
+
+	"blog-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type MFARepo struct {
+	DB *gorm.DB
+}
+
+func NewMFARepo(db *gorm.DB) *MFARepo {
+	return &MFARepo{DB: db}
+}
+
+// 绑定第一步：保存待确认的密钥（已启用时不覆盖，返回 gorm.ErrRecordNotFound）
+func (r *MFARepo) SetPendingSecret(userID uint, secret string) error {
+	res := r.DB.Model(&models.User{}).
+		Where("id = ? AND mfa_enabled_at IS NULL", userID).
+		Update("totp_secret", secret)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// 绑定第二步：启用二次验证并写入恢复码
+func (r *MFARepo) Enable(userID uint, step int64, codeHashes []string, now time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.User{}).
+			Where("id = ? AND mfa_enabled_at IS NULL", userID).
+			Updates(map[string]any{"mfa_enabled_at": now, "totp_last_step": step})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// 关闭二次验证：清空密钥与恢复码
+func (r *MFARepo) Disable(userID uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]any{"totp_secret": "", "mfa_enabled_at": nil, "totp_last_step": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+	})
+}
+
+// 重新生成恢复码，旧的全部作废
+func (r *MFARepo) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+	rows := make([]models.MFARecoveryCode, 0, len(codeHashes))
+	for _, h := range codeHashes {
+		rows = append(rows, models.MFARecoveryCode{UserID: userID, CodeHash: h})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
+
+// 消费一个恢复码；不存在或已使用时返回 gorm.ErrRecordNotFound
+func (r *MFARepo) UseRecoveryCode(userID uint, codeHash string, now time.Time) error {
+	res := r.DB.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", now)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *MFARepo) CountUnusedRecoveryCodes(userID uint) (int64, error) {
+	var cnt int64
+	err := r.DB.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&cnt).Error
+	return cnt, err
+}
+
+// 记录已使用的 TOTP 步数；步数不大于上次时返回 false（验证码重放）
+func (r *MFARepo) AdvanceTOTPStep(userID uint, step int64) (bool, error) {
+	res := r.DB.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *MFARepo) CreateChallenge(ch *models.MFAChallenge) error {
+	return r.DB.Create(ch).Error
+}
+
+// 查找未使用且未过期的登录挑战
+func (r *MFARepo) FindValidChallenge(hash string, now time.Time) (*models.MFAChallenge, error) {
+	var ch models.MFAChallenge
+	if err := r.DB.
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hash, now).
+		First(&ch).Error; err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// 验证码错误：累计次数，达到 max 后直接作废
+func (r *MFARepo) FailChallenge(id uint, max uint, now time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.MFAChallenge{}).
+			Where("id = ?", id).
+			Update("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.MFAChallenge{}).
+			Where("id = ? AND attempts >= ? AND used_at IS NULL", id, max).
+			Update("used_at", now).Error
+	})
+}
+
+// 标记挑战已使用；已被并发使用时返回 gorm.ErrRecordNotFound
+func (r *MFARepo) ConsumeChallenge(id uint, now time.Time) error {
+	res := r.DB.Model(&models.MFAChallenge{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", now)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *MFARepo) PurgeExpiredChallenges(now time.Time) (int64, error) {
+	res := r.DB.Where("expires_at < ?", now).Delete(&models.MFAChallenge{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"time"
+
+	"blog-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PasswordResetRepo struct {
+	DB *gorm.DB
+}
+
+func NewPasswordResetRepo(db *gorm.DB) *PasswordResetRepo {
+	return &PasswordResetRepo{DB: db}
+}
+
+func (r *PasswordResetRepo) Create(t *models.PasswordResetToken) error {
+	return r.DB.Create(t).Error
+}
+
+/*
+* 按用户限流后创建 token：同一事务内锁住用户行再统计与插入，并发请求按顺序执行，不会同时通过限流
+* @param minInterval 两次签发的最小间隔
+* @param hourlyLimit 一小时内最多签发次数
+* @return created 是否创建（触发限流时为 false）
+ */
+func (r *PasswordResetRepo) CreateThrottled(t *models.PasswordResetToken, now time.Time, minInterval time.Duration, hourlyLimit int64) (created bool, err error) {
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		var id uint
+		if err := tx.Model(&models.User{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", t.UserID).
+			Pluck("id", &id).Error; err != nil {
+			return err
+		}
+
+		var recent, hourly int64
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND created_at >= ?", t.UserID, now.Add(-minInterval)).
+			Count(&recent).Error; err != nil {
+			return err
+		}
+		if recent > 0 {
+			return nil
+		}
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND created_at >= ?", t.UserID, now.Add(-time.Hour)).
+			Count(&hourly).Error; err != nil {
+			return err
+		}
+		if hourly >= hourlyLimit {
+			return nil
+		}
+
+		t.CreatedAt = now
+		if err := tx.Create(t).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+// 查找未使用且未过期的 token
+func (r *PasswordResetRepo) FindValidByHash(hash string, now time.Time) (*models.PasswordResetToken, error) {
+	var t models.PasswordResetToken
+	if err := r.DB.
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hash, now).
+		First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// 消费 token 并更新密码：同一事务内标记该用户所有未用 token 失效，防止并发重复使用
+func (r *PasswordResetRepo) ConsumeAndSetPassword(t *models.PasswordResetToken, passwordHash string, now time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", t.ID).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", t.UserID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).
+			Where("id = ?", t.UserID).
+			Updates(map[string]any{
+				"password_hash":           passwordHash,
+				"password_reset_required": false,
+			}).Error; err != nil {
+			return err
+		}
+		// 清除该账号所有 IP 上的登录失败锁定
+		return tx.Where("user_id = ?", t.UserID).Delete(&models.LoginFailure{}).Error
+	})
+}
@@ -1,9 +1,15 @@
 package repositories
 
 import (
+	"slices"
+	"strings"
+	"time"
+
 	"blog-service/internal/models"
+	"blog-service/internal/utils/cursor"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostRepo struct {
@@ -22,6 +28,36 @@ func (r *PostRepo) Update(p *models.Post) error {
 	return r.DB.Save(p).Error
 }
 
+// 创建文章并写入首个修订快照（同一事务）
+func (r *PostRepo) CreateWithRevision(p *models.Post, rev *models.PostRevision) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(p).Error; err != nil {
+			return err
+		}
+		rev.PostID = p.ID
+		return tx.Create(rev).Error
+	})
+}
+
+// 保存文章并按顺序追加修订快照（同一事务）
+func (r *PostRepo) UpdateWithRevisions(p *models.Post, revs ...*models.PostRevision) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(p).Error; err != nil {
+			return err
+		}
+		// Save 只会追加关联，标签需要整体替换（回滚时可能减少标签）
+		if err := tx.Model(p).Association("Tags").Replace(p.Tags); err != nil {
+			return err
+		}
+		for _, rev := range revs {
+			if err := tx.Create(rev).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (r *PostRepo) DeleteByID(id uint) error {
 	return r.DB.Delete(&models.Post{}, id).Error
 }
@@ -38,6 +74,7 @@ func (r *PostRepo) FindBySlugPublished(slug string) (*models.Post, error) {
 	var p models.Post
 	if err := r.DB.Preload("Tags").
 		Preload("Author").
+		Preload("Category").
 		Where("slug = ? AND status = ?", slug, models.PostPublished).
 		First(&p).Error; err != nil {
 		return nil, err
@@ -49,6 +86,7 @@ func (r *PostRepo) FindBySlugAny(slug string) (*models.Post, error) {
 	var p models.Post
 	if err := r.DB.Preload("Tags").
 		Preload("Author").
+		Preload("Category").
 		Where("slug = ?", slug).
 		First(&p).Error; err != nil {
 		return nil, err
@@ -64,7 +102,294 @@ func (r *PostRepo) SlugExists(slug string) (bool, error) {
 	return cnt > 0, nil
 }
 
+// 列表分页参数：Cursor 为空时按 Page 偏移分页，否则按游标（键集）翻页
+type PageQuery struct {
+	Page int
+	Size int
+	// 上一次响应中的 next_cursor / prev_cursor；与排序方式不匹配时返回 cursor.ErrInvalid
+	Cursor string
+	// 是否统计总数（COUNT(*) 在大表上较慢，游标翻页时通常不需要）
+	Total bool
+}
+
+type PostPage struct {
+	Items []models.Post
+	Total *int64 // 未统计时为 nil
+	// 是否还有下一页/上一页，用于生成 next/prev 游标
+	HasNext bool
+	HasPrev bool
+
+	order postOrder
+}
+
+type PostSort string
+
+const (
+	SortNewest   PostSort = "newest"
+	SortOldest   PostSort = "oldest"
+	SortViews    PostSort = "views"
+	SortLikes    PostSort = "likes"
+	SortComments PostSort = "comments"
+)
+
+func ValidPostSort(s PostSort) bool {
+	switch s {
+	case SortNewest, SortOldest, SortViews, SortLikes, SortComments:
+		return true
+	}
+	return false
+}
+
+// 列表筛选条件，零值表示不过滤
+type PostFilter struct {
+	Tags    []string // 已规范化的标签名
+	AllTags bool     // true：包含全部标签；false：包含任一标签
+	Author  string   // 作者用户名
+	// 发布时间范围 [From, To)
+	From *time.Time
+	To   *time.Time
+	Sort PostSort // 为空时按 newest
+}
+
+// 列表不返回正文
+const postListColumns = "id,title,slug,status,published_at,author_id,category_id,view_count,like_count,comment_count,created_at,updated_at"
+
 func (r *PostRepo) ListPublished(page, size int) ([]models.Post, int64, error) {
+	res, err := r.ListPublishedPage(PostFilter{}, PageQuery{Page: page, Size: size, Total: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	return res.Items, *res.Total, nil
+}
+
+// 已发布文章；newest/oldest 按 (published_at, id) 排序
+func (r *PostRepo) ListPublishedPage(f PostFilter, q PageQuery) (*PostPage, error) {
+	base := r.DB.Model(&models.Post{}).Where("status = ?", models.PostPublished)
+	return r.listPage(r.applyPostFilter(base, f), newPostOrder(f.Sort, "published_at"), q)
+}
+
+func (r *PostRepo) ListAny(status *models.PostStatus, page, size int) ([]models.Post, int64, error) {
+	res, err := r.ListAnyPage(status, PostFilter{}, PageQuery{Page: page, Size: size, Total: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	return res.Items, *res.Total, nil
+}
+
+// 任意状态（status 为 nil 时不过滤）；newest/oldest 按 (created_at, id) 排序
+func (r *PostRepo) ListAnyPage(status *models.PostStatus, f PostFilter, q PageQuery) (*PostPage, error) {
+	base := r.DB.Model(&models.Post{})
+	if status != nil {
+		base = base.Where("status = ?", *status)
+	}
+	return r.listPage(r.applyPostFilter(base, f), newPostOrder(f.Sort, "created_at"), q)
+}
+
+func (r *PostRepo) applyPostFilter(q *gorm.DB, f PostFilter) *gorm.DB {
+	if len(f.Tags) > 0 {
+		sub := r.DB.Table("post_tags").
+			Select("post_tags.post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").
+			Where("tags.name IN ?", f.Tags)
+		if f.AllTags {
+			sub = sub.Group("post_tags.post_id").Having("COUNT(DISTINCT post_tags.tag_id) = ?", len(f.Tags))
+		}
+		q = q.Where("id IN (?)", sub)
+	}
+	if f.Author != "" {
+		q = q.Where("author_id IN (?)", r.DB.Table("users").Select("id").Where("username = ?", f.Author))
+	}
+	if f.From != nil {
+		q = q.Where("published_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where("published_at < ?", *f.To)
+	}
+	return q
+}
+
+// 排序方式：主排序列 + id 兜底，游标 key 与之一一对应
+type postOrder struct {
+	key   string
+	col   string
+	asc   bool
+	count bool // 计数列（游标取 N），否则为时间列
+}
+
+// 复合索引：idx_posts_status_published / idx_posts_status_created / idx_posts_status_{views,likes,comments}
+func newPostOrder(sort PostSort, timeCol string) postOrder {
+	// 游标 key：published / created，升序加 _asc
+	timeKey := strings.TrimSuffix(timeCol, "_at")
+	switch sort {
+	case SortOldest:
+		return postOrder{key: timeKey + "_asc", col: timeCol, asc: true}
+	case SortViews:
+		return postOrder{key: "views", col: "view_count", count: true}
+	case SortLikes:
+		return postOrder{key: "likes", col: "like_count", count: true}
+	case SortComments:
+		return postOrder{key: "comments", col: "comment_count", count: true}
+	}
+	return postOrder{key: timeKey, col: timeCol}
+}
+
+func (o postOrder) value(p models.Post) (time.Time, uint64) {
+	switch o.col {
+	case "view_count":
+		return time.Time{}, p.ViewCount
+	case "like_count":
+		return time.Time{}, p.LikeCount
+	case "comment_count":
+		return time.Time{}, p.CommentCount
+	case "published_at":
+		if p.PublishedAt != nil {
+			return *p.PublishedAt, 0
+		}
+	}
+	return p.CreatedAt, 0
+}
+
+// 由首尾两条生成 prev/next 游标；没有更多时为空串
+func (pg *PostPage) Cursors() (next, prev string) {
+	if len(pg.Items) == 0 {
+		return "", ""
+	}
+	at := func(p models.Post, back bool) string {
+		t, n := pg.order.value(p)
+		return cursor.Cursor{Key: pg.order.key, Time: t, N: n, ID: p.ID, Prev: back}.Encode()
+	}
+	if pg.HasNext {
+		next = at(pg.Items[len(pg.Items)-1], false)
+	}
+	if pg.HasPrev {
+		prev = at(pg.Items[0], true)
+	}
+	return next, prev
+}
+
+// 按 (col, id) 分页；多取一条判断是否还有更多。
+// 游标翻页用 WHERE (col, id) < (?, ?) 定位，不受新发布文章影响，也不需要扫描 OFFSET 之前的行
+func (r *PostRepo) listPage(base *gorm.DB, o postOrder, q PageQuery) (*PostPage, error) {
+	page, size := q.Page, q.Size
+	if page < 1 {
+		page = 1
+	}
+	if size <= 0 || size > 50 {
+		size = 10
+	}
+
+	var c *cursor.Cursor
+	if q.Cursor != "" {
+		var err error
+		if c, err = cursor.Decode(q.Cursor, o.key); err != nil {
+			return nil, err
+		}
+	}
+
+	out := &PostPage{order: o}
+	if q.Total {
+		var total int64
+		if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		out.Total = &total
+	}
+
+	query := base.Session(&gorm.Session{}).
+		Select(postListColumns).
+		Preload("Tags").
+		Preload("Author").
+		Preload("Category")
+
+	// 向后翻页沿排序方向取；上一页反向取，再倒回来
+	asc := o.asc
+	if c != nil && c.Prev {
+		asc = !asc
+	}
+	dir, cmp := " DESC", "<"
+	if asc {
+		dir, cmp = " ASC", ">"
+	}
+	if c == nil {
+		query = query.Offset((page - 1) * size)
+	} else {
+		var v any = c.Time
+		if o.count {
+			v = c.N
+		}
+		query = query.Where("("+o.col+" "+cmp+" ? OR ("+o.col+" = ? AND id "+cmp+" ?))", v, v, c.ID)
+	}
+	query = query.Order(o.col + dir + ", id" + dir)
+
+	var items []models.Post
+	if err := query.Limit(size + 1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	more := len(items) > size
+	if more {
+		items = items[:size]
+	}
+
+	switch {
+	case c == nil:
+		out.HasNext, out.HasPrev = more, page > 1
+	case c.Prev:
+		slices.Reverse(items)
+		out.HasNext, out.HasPrev = true, more
+	default:
+		out.HasNext, out.HasPrev = more, true
+	}
+	out.Items = items
+	return out, nil
+}
+
+// 每条 UPDATE 最多涉及的文章数
+const viewCountChunk = 500
+
+/*
+* 批量累加浏览量（浏览计数器定期写回）
+* @param counts 文章 id -> 新增浏览量；按 id 升序加锁，多实例同时写回不会死锁
+ */
+func (r *PostRepo) AddViewCounts(counts map[uint]uint64) error {
+	ids := make([]uint, 0, len(counts))
+	for id, n := range counts {
+		if n > 0 {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	for len(ids) > 0 {
+		chunk := ids[:min(len(ids), viewCountChunk)]
+		ids = ids[len(chunk):]
+
+		var sb strings.Builder
+		args := make([]any, 0, len(chunk)*2)
+		sb.WriteString("view_count + CASE id")
+		for _, id := range chunk {
+			sb.WriteString(" WHEN ? THEN ?")
+			args = append(args, id, counts[id])
+		}
+		sb.WriteString(" ELSE 0 END")
+		// UpdateColumn 不更新 updated_at
+		err := r.DB.Model(&models.Post{}).
+			Where("id IN ?", chunk).
+			UpdateColumn("view_count", gorm.Expr(sb.String(), args...)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type PostSearchHit struct {
+	Post  models.Post
+	Score float64
+}
+
+// 全文检索（ft_posts_title_md）：boolean=true 使用 BOOLEAN MODE，否则 NATURAL LANGUAGE MODE
+// onlyPublished=true 时只返回已发布文章；结果按相关度降序
+func (r *PostRepo) Search(q string, boolean, onlyPublished bool, page, size int) ([]PostSearchHit, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -73,26 +398,160 @@ func (r *PostRepo) ListPublished(page, size int) ([]models.Post, int64, error) {
 	}
 	offset := (page - 1) * size
 
+	match := "MATCH(title, content_md) AGAINST (? IN NATURAL LANGUAGE MODE)"
+	if boolean {
+		match = "MATCH(title, content_md) AGAINST (? IN BOOLEAN MODE)"
+	}
+
+	base := r.DB.Model(&models.Post{}).Where(match, q)
+	if onlyPublished {
+		base = base.Where("status = ?", models.PostPublished)
+	}
+
 	var total int64
-	if err := r.DB.Model(&models.Post{}).
-		Where("status = ?", models.PostPublished).
-		Count(&total).Error; err != nil {
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return []PostSearchHit{}, 0, nil
+	}
+
+	// 先取 id + 分数，再按 id 加载文章（便于 Preload）
+	var rows []struct {
+		ID    uint
+		Score float64
+	}
+	if err := base.Session(&gorm.Session{}).
+		Select("id, "+match+" AS score", q).
+		Order("score DESC, id DESC").
+		Offset(offset).Limit(size).
+		Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(rows) == 0 {
+		return []PostSearchHit{}, total, nil
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	var posts []models.Post
+	if err := r.DB.Preload("Tags").
+		Preload("Author").
+		Preload("Category").
+		Where("id IN ?", ids).
+		Find(&posts).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uint]models.Post, len(posts))
+	for _, p := range posts {
+		byID[p.ID] = p
+	}
+
+	hits := make([]PostSearchHit, 0, len(rows))
+	for _, row := range rows {
+		if p, ok := byID[row.ID]; ok {
+			hits = append(hits, PostSearchHit{Post: p, Score: row.Score})
+		}
+	}
+	return hits, total, nil
+}
+
+// 某标签下的已发布文章，分页方式与 ListPublished 一致
+func (r *PostRepo) ListPublishedByTag(tagName string, page, size int) ([]models.Post, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size <= 0 || size > 50 {
+		size = 10
+	}
+	offset := (page - 1) * size
+
+	q := r.DB.Model(&models.Post{}).
+		Joins("JOIN post_tags ON post_tags.post_id = posts.id").
+		Joins("JOIN tags ON tags.id = post_tags.tag_id").
+		Where("tags.name = ? AND posts.status = ?", tagName, models.PostPublished)
+
+	var total int64
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	var items []models.Post
-	err := r.DB.
-		Select("id,title,slug,status,published_at,author_id,view_count,like_count,comment_count,created_at,updated_at").
+	err := q.
+		Select("posts.id,posts.title,posts.slug,posts.status,posts.published_at,posts.author_id,posts.category_id,posts.view_count,posts.like_count,posts.comment_count,posts.created_at,posts.updated_at").
 		Preload("Tags").
 		Preload("Author").
-		Where("status = ?", models.PostPublished).
-		Order("published_at DESC").
+		Preload("Category").
+		Order("posts.published_at DESC, posts.id DESC").
 		Offset(offset).Limit(size).
 		Find(&items).Error
 	return items, total, err
 }
 
-func (r *PostRepo) ListAny(status *models.PostStatus, page, size int) ([]models.Post, int64, error) {
+// 订阅源筛选条件；都为空时为全站
+type FeedFilter struct {
+	TagName  string
+	AuthorID uint
+}
+
+// 订阅源：最近发布的文章，包含正文 HTML
+func (r *PostRepo) ListFeed(f FeedFilter, limit int) ([]models.Post, error) {
+	q := r.DB.Model(&models.Post{}).Where("posts.status = ?", models.PostPublished)
+	if f.TagName != "" {
+		q = q.Joins("JOIN post_tags ON post_tags.post_id = posts.id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").
+			Where("tags.name = ?", f.TagName)
+	}
+	if f.AuthorID != 0 {
+		q = q.Where("posts.author_id = ?", f.AuthorID)
+	}
+
+	var items []models.Post
+	err := q.
+		Select("posts.id,posts.title,posts.slug,posts.content_html,posts.status,posts.published_at,posts.author_id,posts.created_at,posts.updated_at").
+		Preload("Tags").
+		Preload("Author").
+		Order("posts.published_at DESC, posts.id DESC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+func (r *PostRepo) CountPublished() (int64, error) {
+	var cnt int64
+	err := r.DB.Model(&models.Post{}).Where("status = ?", models.PostPublished).Count(&cnt).Error
+	return cnt, err
+}
+
+// sitemap：已发布文章按 id 升序分页（新文章只会追加到最后一页），只取 slug 与时间
+func (r *PostRepo) ListSitemap(offset, limit int) ([]models.Post, error) {
+	var items []models.Post
+	err := r.DB.Model(&models.Post{}).
+		Select("id,slug,published_at,updated_at").
+		Where("status = ?", models.PostPublished).
+		Order("id ASC").
+		Offset(offset).Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// 与 ListSitemap 同一分页内最近的更新/发布时间；没有文章时返回 nil
+func (r *PostRepo) SitemapLastMod(offset, limit int) (*time.Time, error) {
+	page := r.DB.Model(&models.Post{}).
+		Select("GREATEST(updated_at, COALESCE(published_at, updated_at)) AS lastmod").
+		Where("status = ?", models.PostPublished).
+		Order("id ASC").
+		Offset(offset).Limit(limit)
+
+	var t *time.Time
+	err := r.DB.Table("(?) AS page", page).Select("MAX(lastmod)").Scan(&t).Error
+	return t, err
+}
+
+// 指定分类集合（通常是某分类及其全部子孙）下的已发布文章
+func (r *PostRepo) ListPublishedByCategories(categoryIDs []uint, page, size int) ([]models.Post, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -101,29 +560,46 @@ func (r *PostRepo) ListAny(status *models.PostStatus, page, size int) ([]models.
 	}
 	offset := (page - 1) * size
 
-	q := r.DB.Model(&models.Post{})
-	if status != nil {
-		q = q.Where("status = ?", *status)
-	}
+	q := r.DB.Model(&models.Post{}).
+		Where("status = ? AND category_id IN ?", models.PostPublished, categoryIDs)
 
 	var total int64
-	if err := q.Count(&total).Error; err != nil {
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	var items []models.Post
 	err := q.
-		Select("id,title,slug,status,published_at,author_id,view_count,like_count,comment_count,created_at,updated_at").
+		Select(postListColumns).
 		Preload("Tags").
 		Preload("Author").
-		Order("created_at DESC").
+		Preload("Category").
+		Order("published_at DESC, id DESC").
 		Offset(offset).Limit(size).
 		Find(&items).Error
 	return items, total, err
 }
 
-func (r *PostRepo) IncViewCount(id uint) error {
-	return r.DB.Model(&models.Post{}).
-		Where("id = ?", id).
-		UpdateColumn("view_count", gorm.Expr("view_count + 1")).Error
+// 把到期的定时文章转为已发布，返回本次发布的文章 id。
+// SELECT ... FOR UPDATE SKIP LOCKED 保证多实例并发扫描时同一篇文章只被一个实例处理；
+// UPDATE 再带上 status 条件，重复执行也是幂等的
+func (r *PostRepo) PublishDue(now time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Post{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND published_at <= ?", models.PostScheduled, now).
+			Order("published_at ASC").
+			Limit(limit).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Post{}).
+			Where("id IN ? AND status = ?", ids, models.PostScheduled).
+			Update("status", models.PostPublished).Error
+	})
+	return ids, err
 }
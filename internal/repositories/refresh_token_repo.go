@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"time"
+
+	"blog-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefreshTokenRepo struct {
+	DB *gorm.DB
+}
+
+func NewRefreshTokenRepo(db *gorm.DB) *RefreshTokenRepo {
+	return &RefreshTokenRepo{DB: db}
+}
+
+func (r *RefreshTokenRepo) Create(t *models.RefreshToken) error {
+	return r.DB.Create(t).Error
+}
+
+// 按哈希查找（含已使用/已吊销的，用于重用检测）
+func (r *RefreshTokenRepo) FindByHash(hash string) (*models.RefreshToken, error) {
+	var t models.RefreshToken
+	if err := r.DB.Where("token_hash = ?", hash).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// 轮换：标记旧 token 已使用并签发同家族的新 token；
+// 旧 token 已被并发使用时返回 gorm.ErrRecordNotFound
+func (r *RefreshTokenRepo) Rotate(old *models.RefreshToken, next *models.RefreshToken, now time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", old.ID).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(next).Error
+	})
+}
+
+// 吊销整个家族：刷新令牌全部失效，并拉黑该家族签发的 access token（sid）
+func (r *RefreshTokenRepo) RevokeFamily(familyID string, now time.Time, accessExpiresAt time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return revoke(tx, models.RevokeSession, familyID, accessExpiresAt)
+	})
+}
+
+// 吊销某用户的全部会话（如重置密码后）
+func (r *RefreshTokenRepo) RevokeAllForUser(userID uint, now time.Time, accessExpiresAt time.Time) error {
+	var families []string
+	if err := r.DB.Model(&models.RefreshToken{}).
+		Distinct("family_id").
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Pluck("family_id", &families).Error; err != nil {
+		return err
+	}
+	for _, f := range families {
+		if err := r.RevokeFamily(f, now, accessExpiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 拉黑单个 access token
+func (r *RefreshTokenRepo) RevokeJTI(jti string, expiresAt time.Time) error {
+	return revoke(r.DB, models.RevokeJTI, jti, expiresAt)
+}
+
+func revoke(db *gorm.DB, kind models.RevocationKind, value string, expiresAt time.Time) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.TokenRevocation{
+		Kind:      kind,
+		Value:     value,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// access token 是否已被吊销（按 jti 或所属家族 sid）
+func (r *RefreshTokenRepo) IsRevoked(jti, sid string) (bool, error) {
+	q := r.DB.Model(&models.TokenRevocation{}).Where("kind = ? AND value = ?", models.RevokeJTI, jti)
+	if sid != "" {
+		q = q.Or("kind = ? AND value = ?", models.RevokeSession, sid)
+	}
+	var cnt int64
+	err := q.Limit(1).Count(&cnt).Error
+	return cnt > 0, err
+}
+
+// 清理过期的刷新令牌与吊销记录
+func (r *RefreshTokenRepo) PurgeExpired(now time.Time) (int64, error) {
+	res := r.DB.Where("expires_at < ?", now).Delete(&models.RefreshToken{})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	n := res.RowsAffected
+	res = r.DB.Where("expires_at < ?", now).Delete(&models.TokenRevocation{})
+	if res.Error != nil {
+		return n, res.Error
+	}
+	return n + res.RowsAffected, nil
+}
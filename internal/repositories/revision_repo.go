@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"blog-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type RevisionRepo struct {
+	DB *gorm.DB
+}
+
+func NewRevisionRepo(db *gorm.DB) *RevisionRepo {
+	return &RevisionRepo{DB: db}
+}
+
+func (r *RevisionRepo) Create(rev *models.PostRevision) error {
+	return r.DB.Create(rev).Error
+}
+
+func (r *RevisionRepo) CountByPost(postID uint) (int64, error) {
+	var cnt int64
+	err := r.DB.Model(&models.PostRevision{}).Where("post_id = ?", postID).Count(&cnt).Error
+	return cnt, err
+}
+
+// 修订列表（不含正文），最新在前
+func (r *RevisionRepo) ListByPost(postID uint) ([]models.PostRevision, error) {
+	var items []models.PostRevision
+	err := r.DB.
+		Select("id,post_id,title,tag_names,editor_id,created_at").
+		Preload("Editor").
+		Where("post_id = ?", postID).
+		Order("id DESC").
+		Find(&items).Error
+	return items, err
+}
+
+func (r *RevisionRepo) FindByID(postID, revID uint) (*models.PostRevision, error) {
+	var rev models.PostRevision
+	if err := r.DB.Preload("Editor").
+		Where("post_id = ?", postID).
+		First(&rev, revID).Error; err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
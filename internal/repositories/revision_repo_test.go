@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 不连接数据库，只生成 SQL；每次查询后校验 Select 的列都在模型中存在
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "u:p@tcp(127.0.0.1:1)/x?parseTime=true",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Callback().Query().After("gorm:query").Register("test:check_selects", func(tx *gorm.DB) {
+		st := tx.Statement
+		for _, sel := range st.Selects {
+			for _, col := range strings.Split(sel, ",") {
+				col = strings.TrimSpace(col)
+				if st.Schema.LookUpField(col) == nil {
+					t.Errorf("%s: unknown column %q in %s", st.Schema.Table, col, st.SQL.String())
+				}
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestRevisionListSelectsExistingColumns(t *testing.T) {
+	if _, err := NewRevisionRepo(dryRunDB(t)).ListByPost(1); err != nil {
+		t.Fatal(err)
+	}
+}
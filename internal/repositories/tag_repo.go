@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"strings"
+	"time"
 
 	"blog-service/internal/models"
 
@@ -58,3 +59,97 @@ func (r *TagRepo) GetOrCreateByNames(names []string) ([]models.Tag, error) {
 	}
 	return out, nil
 }
+
+type TagWithCount struct {
+	ID        uint
+	Name      string
+	PostCount int64
+}
+
+// 带已发布文章数的标签列表（只返回至少有一篇已发布文章的标签）
+// sort: popular（默认，按文章数）/ name
+func (r *TagRepo) ListWithCounts(sort string) ([]TagWithCount, error) {
+	order := "post_count DESC, tags.name ASC"
+	if sort == "name" {
+		order = "tags.name ASC"
+	}
+
+	var items []TagWithCount
+	err := r.DB.Table("tags").
+		Select("tags.id, tags.name, COUNT(posts.id) AS post_count").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.status = ?", models.PostPublished).
+		Group("tags.id, tags.name").
+		Order(order).
+		Scan(&items).Error
+	return items, err
+}
+
+type TagLastMod struct {
+	Name    string
+	LastMod time.Time
+}
+
+// sitemap：有已发布文章的标签，及其下文章最近的更新时间
+func (r *TagRepo) ListSitemap() ([]TagLastMod, error) {
+	var items []TagLastMod
+	err := r.DB.Table("tags").
+		Select("tags.name, MAX(posts.updated_at) AS last_mod").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.status = ?", models.PostPublished).
+		Group("tags.id, tags.name").
+		Order("tags.name ASC").
+		Scan(&items).Error
+	return items, err
+}
+
+func (r *TagRepo) FindByID(id uint) (*models.Tag, error) {
+	var t models.Tag
+	if err := r.DB.First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *TagRepo) FindByName(name string) (*models.Tag, error) {
+	var t models.Tag
+	if err := r.DB.Where("name = ?", normalizeTag(name)).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *TagRepo) Rename(id uint, name string) error {
+	return r.DB.Model(&models.Tag{}).
+		Where("id = ?", id).
+		Update("name", normalizeTag(name)).Error
+}
+
+// 把标签 from 合并到 into：改写 post_tags（已同时带两个标签的文章不重复），再删除 from
+func (r *TagRepo) Merge(fromID, intoID uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO post_tags (post_id, tag_id)
+			SELECT pt.post_id, ? FROM post_tags pt
+			WHERE pt.tag_id = ?
+			  AND NOT EXISTS (
+				SELECT 1 FROM post_tags x WHERE x.post_id = pt.post_id AND x.tag_id = ?
+			  )
+		`, intoID, fromID, intoID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM post_tags WHERE tag_id = ?", fromID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Tag{}, fromID).Error
+	})
+}
+
+// 删除没有任何文章引用的标签，返回删除数量
+func (r *TagRepo) DeleteUnused() (int64, error) {
+	res := r.DB.Exec(`
+		DELETE FROM tags
+		WHERE NOT EXISTS (SELECT 1 FROM post_tags pt WHERE pt.tag_id = tags.id)
+	`)
+	return res.RowsAffected, res.Error
+}
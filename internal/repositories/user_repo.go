@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"blog-service/internal/models"
@@ -42,6 +43,24 @@ func (r *UserRepo) FindByEmailOrUsername(s string) (*models.User, error) {
 	return &u, nil
 }
 
+// 根据邮箱查找用户
+func (r *UserRepo) FindByEmail(email string) (*models.User, error) {
+	var u models.User
+	if err := r.DB.Where("email = ?", email).First(&u).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// 根据用户名查找用户
+func (r *UserRepo) FindByUsername(username string) (*models.User, error) {
+	var u models.User
+	if err := r.DB.Where("username = ?", username).First(&u).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
 // 用于注册时检查唯一性
 func (r *UserRepo) ExistsEmail(email string) (bool, error) {
 	var cnt int64
@@ -71,3 +90,142 @@ func (r *UserRepo) UpdateLastLoginAt(userID uint, t time.Time) error {
 		Where("id = ?", userID).
 		Update("last_login_at", t).Error
 }
+
+// 标记邮箱已验证；已验证过的不覆盖时间
+func (r *UserRepo) MarkEmailVerified(userID uint, t time.Time) error {
+	return r.DB.Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NULL", userID).
+		Update("email_verified_at", t).Error
+}
+
+func (r *UserRepo) SetVerificationSentAt(userID uint, t time.Time) error {
+	return r.DB.Model(&models.User{}).Where("id = ?", userID).Update("verification_sent_at", t).Error
+}
+
+// 邮箱是否已验证（供评论/点赞前的中间件调用）
+func (r *UserRepo) EmailVerified(userID uint) (bool, error) {
+	var cnt int64
+	err := r.DB.Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NOT NULL", userID).
+		Count(&cnt).Error
+	return cnt > 0, err
+}
+
+// 当前角色与账号是否可用（供鉴权中间件每次请求调用）
+func (r *UserRepo) ActiveRole(userID uint) (models.UserRole, bool, error) {
+	var u models.User
+	err := r.DB.Select("id", "role", "disabled_at").First(&u, userID).Error
+	if err != nil {
+		if IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return u.Role, u.DisabledAt == nil, nil
+}
+
+type UserFilter struct {
+	Query    string // 模糊匹配邮箱/用户名
+	Role     *models.UserRole
+	Disabled *bool
+}
+
+func (r *UserRepo) List(f UserFilter, page, size int) ([]models.User, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > 100 {
+		size = 20
+	}
+
+	q := r.DB.Model(&models.User{})
+	if s := strings.TrimSpace(f.Query); s != "" {
+		like := "%" + escapeLike(s) + "%"
+		q = q.Where("email LIKE ? OR username LIKE ?", like, like)
+	}
+	if f.Role != nil {
+		q = q.Where("role = ?", *f.Role)
+	}
+	if f.Disabled != nil {
+		if *f.Disabled {
+			q = q.Where("disabled_at IS NOT NULL")
+		} else {
+			q = q.Where("disabled_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var items []models.User
+	err := q.Order("id DESC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&items).Error
+	return items, total, err
+}
+
+// LIKE 通配符转义
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (r *UserRepo) UpdateRole(userID uint, role models.UserRole) error {
+	return r.DB.Model(&models.User{}).Where("id = ?", userID).Update("role", role).Error
+}
+
+// at 为 nil 表示恢复启用
+func (r *UserRepo) SetDisabledAt(userID uint, at *time.Time) error {
+	return r.DB.Model(&models.User{}).Where("id = ?", userID).Update("disabled_at", at).Error
+}
+
+func (r *UserRepo) SetPasswordResetRequired(userID uint, required bool) error {
+	return r.DB.Model(&models.User{}).Where("id = ?", userID).Update("password_reset_required", required).Error
+}
+
+func (r *UserRepo) CountByRole(role models.UserRole) (int64, error) {
+	var cnt int64
+	err := r.DB.Model(&models.User{}).Where("role = ? AND disabled_at IS NULL", role).Count(&cnt).Error
+	return cnt, err
+}
+
+// 删除用户：文章、评论、修订与媒体的归属转给 toID（这些外键是 RESTRICT），
+// 点赞记录删除并回退计数，令牌类数据直接删除
+func (r *UserRepo) DeleteAndReassign(userID, toID uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		reassign := []struct {
+			model  any
+			column string
+		}{
+			{&models.Post{}, "author_id"},
+			{&models.Comment{}, "author_id"},
+			{&models.PostRevision{}, "editor_id"},
+			{&models.Media{}, "uploader_id"},
+		}
+		for _, it := range reassign {
+			if err := tx.Model(it.model).Where(it.column+" = ?", userID).Update(it.column, toID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec(`
+			UPDATE posts p
+			JOIN post_likes l ON l.post_id = p.id
+			SET p.like_count = GREATEST(p.like_count, 1) - 1
+			WHERE l.user_id = ?
+		`, userID).Error; err != nil {
+			return err
+		}
+		for _, m := range []any{
+			&models.PostLike{}, &models.PasswordResetToken{}, &models.RefreshToken{},
+			&models.MFARecoveryCode{}, &models.MFAChallenge{}, &models.UserIdentity{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&models.User{}, userID).Error
+	})
+}
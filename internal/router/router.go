@@ -1,14 +1,24 @@
 package router
 
 import (
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"blog-service/internal/handlers"
+	"blog-service/internal/mailer"
 	"blog-service/internal/middleware"
+	"blog-service/internal/models"
+	"blog-service/internal/oauth"
+	"blog-service/internal/ratelimit"
 	"blog-service/internal/repositories"
 	"blog-service/internal/services"
+	"blog-service/internal/sitemap"
+	"blog-service/internal/storage"
+	"blog-service/internal/theme"
 	jwtutil "blog-service/internal/utils/jwt"
+	"blog-service/internal/viewcount"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -19,11 +29,68 @@ type Deps struct {
 	DB     *gorm.DB
 	PingDB func() error
 
-	JWTSecret string
+	// access token 签发/验签（HS256 或 RS256/EdDSA）
+	JWT jwtutil.Manager
+	// refresh token 有效期，为 0 时使用默认值
+	RefreshTokenTTL time.Duration
+	Mailer          mailer.Mailer
+
+	Storage        storage.Storage
+	UploadMaxBytes int64
+
+	CommentModeration bool
+
+	// 站点对外地址与邮箱验证链接签名密钥
+	BaseURL           string
+	EmailVerifySecret []byte
+	// 未验证邮箱的用户不能评论、点赞
+	RequireVerifiedEmail bool
+
+	// 站点名称与简介（订阅源标题等）
+	SiteTitle       string
+	SiteDescription string
+	SiteLanguage    string
+
+	// 服务端渲染的公开页面，为 nil 时不注册（只提供 JSON API）
+	Theme *theme.Theme
+
+	// robots.txt：RobotsTxt 非空时原样输出，否则按 RobotsDisallow 生成并声明 sitemap
+	RobotsTxt      []byte
+	RobotsDisallow []string
+	// sitemap 服务（缓存与定时发布任务共用），为 nil 时不提供 sitemap
+	Sitemap *services.SitemapService
+
+	// 浏览量计数器（由 main 启动写回并在退出时 FlushNow），为 nil 时不统计
+	Views *viewcount.Counter
+
+	// 第三方登录提供方（回调地址需与 BaseURL 一致）与 state cookie 签名密钥
+	OAuthProviders   []oauth.Provider
+	OAuthStateSecret []byte
+
+	// 管理员必须通过 TOTP 二次验证登录才有管理权限
+	RequireAdminMFA bool
+	MFAIssuer       string
+
+	// 限流存储，为 nil 时不限流
+	RateLimit ratelimit.Store
+	// 可信反向代理；为空时不信任任何代理头，ClientIP 取直连地址
+	TrustedProxies []string
 }
 
+// 限流规则
+var (
+	apiRateRule   = ratelimit.PerMinute(300, 100) // 全局：每 IP
+	authRateRule  = ratelimit.PerMinute(10, 10)   // 登录/注册/找回密码：每 IP
+	writeRateRule = ratelimit.PerMinute(30, 10)   // 评论/点赞等写操作：每用户每路由
+	// 重发验证邮件：每用户任意一小时内最多 5 封（突发 3 封 + 每小时补充 2 封）
+	resendRateRule = ratelimit.Rule{Rate: 2.0 / 3600, Burst: 3}
+)
+
 func New(d Deps) *gin.Engine {
 	r := gin.New()
+	if err := r.SetTrustedProxies(d.TrustedProxies); err != nil {
+		log.Fatalf("invalid trusted proxies: %v", err)
+	}
 	r.Use(gin.Logger())
 	r.Use(middleware.RecoveryJSON())
 
@@ -31,10 +98,36 @@ func New(d Deps) *gin.Engine {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
 	})
 
+	apiLimit := middleware.RateLimit(d.RateLimit, "api", apiRateRule, middleware.KeyByIP)
+	authLimit := middleware.RateLimit(d.RateLimit, "auth", authRateRule, middleware.KeyByIP)
+	writeLimit := middleware.RateLimit(d.RateLimit, "write", writeRateRule, middleware.KeyByUserRoute)
+	resendLimit := middleware.RateLimit(d.RateLimit, "verify-resend", resendRateRule, middleware.KeyByUserRoute)
+
 	// healthz
 	hh := handlers.HealthHandler{PingDB: d.PingDB}
 	r.GET("/healthz", hh.Healthz)
 
+	// 本地存储时由服务自身提供上传文件访问（不依赖 DB）
+	if ls, ok := d.Storage.(*storage.LocalStorage); ok {
+		r.GET("/uploads/*filepath", handlers.ServeUploads(ls.Dir))
+	}
+
+	robots := d.RobotsTxt
+	if len(robots) == 0 {
+		robots = sitemap.Robots(d.RobotsDisallow, strings.TrimRight(d.BaseURL, "/")+"/sitemap.xml")
+	}
+	r.GET("/robots.txt", handlers.Robots(robots))
+
+	// 公钥集合，供其它服务验签（HS256 模式下 keys 为空）
+	jwksHandler := handlers.JWKSHandler{JWT: d.JWT}
+	r.GET("/.well-known/jwks.json", jwksHandler.Get)
+
+	r.Use(func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			apiLimit(c)
+		}
+	})
+
 	v1 := r.Group("/api/v1")
 	{
 		v1.GET("/ping", func(c *gin.Context) {
@@ -49,49 +142,267 @@ func New(d Deps) *gin.Engine {
 		userRepo := repositories.NewUserRepo(d.DB)
 		postRepo := repositories.NewPostRepo(d.DB)
 		tagRepo := repositories.NewTagRepo(d.DB)
+		commentRepo := repositories.NewCommentRepo(d.DB)
+		likeRepo := repositories.NewLikeRepo(d.DB)
+		resetRepo := repositories.NewPasswordResetRepo(d.DB)
+		mediaRepo := repositories.NewMediaRepo(d.DB)
+		categoryRepo := repositories.NewCategoryRepo(d.DB)
+		revisionRepo := repositories.NewRevisionRepo(d.DB)
+		refreshRepo := repositories.NewRefreshTokenRepo(d.DB)
+		mfaRepo := repositories.NewMFARepo(d.DB)
+		loginFailureRepo := repositories.NewLoginFailureRepo(d.DB)
+		identityRepo := repositories.NewIdentityRepo(d.DB)
 
-		jm := jwtutil.Manager{
-			Secret: []byte(d.JWTSecret),
-			Issuer: "blog-service",
-			TTL:    2 * time.Hour,
+		jm := d.JWT
+		if jm.TTL <= 0 {
+			jm.TTL = 15 * time.Minute
 		}
 
 		authSvc := &services.AuthService{
-			Users: userRepo,
-			JWT:   jm,
+			Users:  userRepo,
+			JWT:    jm,
+			Resets: resetRepo,
+			Mailer: d.Mailer,
+
+			Refresh:       refreshRepo,
+			RefreshTTL:    d.RefreshTokenTTL,
+			LoginFailures: loginFailureRepo,
+
+			MFA:             mfaRepo,
+			MFAIssuer:       d.MFAIssuer,
+			RequireAdminMFA: d.RequireAdminMFA,
+
+			VerifySecret: d.EmailVerifySecret,
+			BaseURL:      d.BaseURL,
 		}
 		postSvc := &services.PostService{
+			Posts:      postRepo,
+			Tags:       tagRepo,
+			Categories: categoryRepo,
+			Revisions:  revisionRepo,
+			Sitemap:    d.Sitemap,
+		}
+		commentSvc := &services.CommentService{
+			Comments:   commentRepo,
+			Posts:      postRepo,
+			Moderation: d.CommentModeration,
+		}
+		searchSvc := &services.SearchService{
+			Posts: postRepo,
+		}
+		mediaSvc := &services.MediaService{
+			Media:    mediaRepo,
+			Storage:  d.Storage,
+			MaxBytes: d.UploadMaxBytes,
+		}
+		tagSvc := &services.TagService{
+			Tags:    tagRepo,
+			Posts:   postRepo,
+			Sitemap: d.Sitemap,
+		}
+		categorySvc := &services.CategoryService{
+			Categories: categoryRepo,
+			Posts:      postRepo,
+			Sitemap:    d.Sitemap,
+		}
+		oauthSvc := &services.OAuthService{
+			Auth:        authSvc,
+			Users:       userRepo,
+			Identities:  identityRepo,
+			Providers:   make(map[string]oauth.Provider, len(d.OAuthProviders)),
+			StateSecret: d.OAuthStateSecret,
+		}
+		for _, p := range d.OAuthProviders {
+			oauthSvc.Providers[p.Name()] = p
+		}
+		feedSvc := &services.FeedService{
+			Posts:       postRepo,
+			Tags:        tagRepo,
+			Users:       userRepo,
+			BaseURL:     d.BaseURL,
+			Title:       d.SiteTitle,
+			Description: d.SiteDescription,
+			Language:    d.SiteLanguage,
+		}
+		userSvc := &services.UserService{
+			Users:   userRepo,
+			Refresh: refreshRepo,
+			Auth:    authSvc,
+		}
+		likeSvc := &services.LikeService{
+			Likes: likeRepo,
 			Posts: postRepo,
-			Tags:  tagRepo,
 		}
-		authMW := middleware.NewAuthMiddleware(jm)
+		authMW := middleware.NewAuthMiddleware(jm, refreshRepo, userRepo)
+		authMW.RequireAdminMFA = d.RequireAdminMFA
+		optionalAuth := authMW.Optional()
+		// 配置要求时，评论与点赞前检查邮箱是否已验证
+		verifiedEmail := func(c *gin.Context) { c.Next() }
+		if d.RequireVerifiedEmail {
+			verifiedEmail = middleware.RequireVerifiedEmail(userRepo)
+		}
 
 		authHandler := handlers.AuthHandler{
 			Auth:  authSvc,
 			Users: userRepo,
 			V:     v,
 		}
+		mfaHandler := handlers.MFAHandler{
+			Auth: authSvc,
+			V:    v,
+		}
+		oauthHandler := handlers.OAuthHandler{
+			OAuth:        oauthSvc,
+			SecureCookie: strings.HasPrefix(d.BaseURL, "https://"),
+		}
 		postHandler := handlers.PostHandler{
 			Posts:    postSvc,
 			PostRepo: postRepo,
+			Likes:    likeRepo,
+			Views:    d.Views,
+			V:        v,
+		}
+		commentHandler := handlers.CommentHandler{
+			Comments: commentSvc,
 			V:        v,
 		}
+		likeHandler := handlers.LikeHandler{
+			Likes: likeSvc,
+		}
+		searchHandler := handlers.SearchHandler{
+			Search: searchSvc,
+		}
+		tagHandler := handlers.TagHandler{
+			Tags: tagSvc,
+			V:    v,
+		}
+		categoryHandler := handlers.CategoryHandler{
+			Categories: categorySvc,
+			V:          v,
+		}
+		uploadHandler := handlers.UploadHandler{
+			Media:    mediaSvc,
+			MaxBytes: d.UploadMaxBytes,
+		}
+		revisionHandler := handlers.RevisionHandler{
+			Posts: postSvc,
+		}
+		userHandler := handlers.UserHandler{
+			Users: userSvc,
+			V:     v,
+		}
+		feedHandler := handlers.FeedHandler{
+			Feeds: feedSvc,
+		}
+
+		// sitemap 索引与分页 sitemap
+		if d.Sitemap != nil {
+			sitemapHandler := handlers.SitemapHandler{
+				Sitemaps: d.Sitemap,
+			}
+			r.GET("/sitemap.xml", sitemapHandler.Index)
+			r.GET("/sitemaps/:name", sitemapHandler.Get)
+		}
+
+		// HTML 页面（可选）
+		if d.Theme != nil {
+			pageHandler := handlers.PageHandler{
+				Theme:      d.Theme,
+				PostRepo:   postRepo,
+				Tags:       tagSvc,
+				Categories: categorySvc,
+				Views:      d.Views,
+				Site: theme.Site{
+					Title:       d.SiteTitle,
+					Description: d.SiteDescription,
+					BaseURL:     strings.TrimRight(d.BaseURL, "/"),
+					Language:    d.SiteLanguage,
+				},
+			}
+			r.GET("/", pageHandler.Home)
+			r.GET("/posts/:slug", pageHandler.Post)
+			r.GET("/tags/:name", pageHandler.Tag)
+			r.GET("/categories/:slug", pageHandler.Category)
+			r.NoRoute(pageHandler.NotFound)
+		}
+
+		// 订阅源：全站、按标签、按作者
+		for _, prefix := range []string{"", "/tags/:name", "/authors/:username"} {
+			r.GET(prefix+"/feed.xml", feedHandler.RSS)
+			r.GET(prefix+"/atom.xml", feedHandler.Atom)
+			r.GET(prefix+"/feed.json", feedHandler.JSON)
+		}
 
 		av1 := r.Group("/api/v1/auth")
 		{
-			av1.POST("/register", authHandler.Register)
-			av1.POST("/login", authHandler.Login)
+			av1.POST("/register", authLimit, authHandler.Register)
+			av1.POST("/login", authLimit, authHandler.Login)
+			av1.POST("/refresh", authLimit, authHandler.Refresh)
+			// access token 过期后仍可凭 refresh token 登出
+			av1.POST("/logout", optionalAuth, authHandler.Logout)
+			av1.POST("/password/forgot", authLimit, authHandler.ForgotPassword)
+			av1.POST("/password/reset", authLimit, authHandler.ResetPassword)
 			av1.GET("/me", authMW.AuthRequired(), authHandler.Me)
+			av1.GET("/email/verify", authLimit, authHandler.VerifyEmail)
+			av1.POST("/email/resend", authMW.AuthRequired(), resendLimit, authHandler.ResendVerification)
+
+			// TOTP 二次验证；verify 为两步登录的第二步，不需要 access token
+			av1.POST("/mfa/verify", authLimit, mfaHandler.Verify)
+			av1.POST("/mfa/setup", authMW.AuthRequired(), authLimit, mfaHandler.Setup)
+			av1.POST("/mfa/enable", authMW.AuthRequired(), authLimit, mfaHandler.Enable)
+			av1.POST("/mfa/disable", authMW.AuthRequired(), authLimit, mfaHandler.Disable)
+			av1.POST("/mfa/recovery-codes", authMW.AuthRequired(), authLimit, mfaHandler.RegenerateRecoveryCodes)
+
+			// 第三方登录（授权码 + PKCE）与身份绑定管理
+			av1.GET("/oauth/providers", oauthHandler.Providers)
+			av1.GET("/oauth/:provider/start", authLimit, oauthHandler.Start)
+			av1.GET("/oauth/:provider/callback", authLimit, oauthHandler.Callback)
+			av1.GET("/identities", authMW.AuthRequired(), oauthHandler.ListIdentities)
+			av1.DELETE("/identities/:id", authMW.AuthRequired(), oauthHandler.Unlink)
 		}
 
 		// 公共：列表 + 详情（如果带 admin token，可看 draft）
 		pv1 := r.Group("/api/v1/posts")
-		pv1.Use(middleware.NewOptionalAuth(jm))
+		pv1.Use(optionalAuth)
 		{
 			pv1.GET("", postHandler.List)
 
 			// 允许带 token
 			pv1.GET("/:slug", postHandler.GetBySlug)
+
+			// 评论：列表公开，写操作需登录
+			pv1.GET("/:slug/comments", commentHandler.List)
+			pv1.POST("/:slug/comments", authMW.AuthRequired(), writeLimit, verifiedEmail, commentHandler.Create)
+			pv1.PUT("/:slug/comments/:id", authMW.AuthRequired(), writeLimit, commentHandler.Update)
+			pv1.DELETE("/:slug/comments/:id", authMW.AuthRequired(), writeLimit, commentHandler.Delete)
+
+			// 点赞：幂等
+			pv1.POST("/:slug/like", authMW.AuthRequired(), writeLimit, verifiedEmail, likeHandler.Like)
+			pv1.DELETE("/:slug/like", authMW.AuthRequired(), writeLimit, likeHandler.Unlike)
+		}
+
+		// 标签浏览
+		tv1 := r.Group("/api/v1/tags")
+		{
+			tv1.GET("", tagHandler.List)
+			tv1.GET("/:name/posts", tagHandler.Posts)
+		}
+
+		// 分类：树 + 分类下文章（含子孙分类）
+		cv1 := r.Group("/api/v1/categories")
+		{
+			cv1.GET("", categoryHandler.Tree)
+			cv1.GET("/:slug/posts", categoryHandler.Posts)
+		}
+
+		// 全文检索（带 admin token 时包含草稿）
+		r.GET("/api/v1/search", optionalAuth, searchHandler.Query)
+
+		// 当前用户相关
+		me := r.Group("/api/v1/me")
+		me.Use(authMW.AuthRequired())
+		{
+			me.GET("/likes", likeHandler.MyLikes)
 		}
 
 		// 示例：管理员保护路由（后续发文章就用这个）
@@ -102,14 +413,62 @@ func New(d Deps) *gin.Engine {
 				c.JSON(http.StatusOK, gin.H{"message": "admin pong"})
 			})
 		}
-		// admin：创建/更新/删除 + 预览
+		// admin：创建/更新/删除 + 预览；作者只能操作自己的文章（见 PostService）
 		adminPosts := r.Group("/api/v1/admin/posts")
-		adminPosts.Use(authMW.AuthRequired(), middleware.RequireAdmin())
+		adminPosts.Use(authMW.AuthRequired(), middleware.RequirePermission(models.PermPostsWrite))
 		{
 			adminPosts.POST("", postHandler.Create)
 			adminPosts.PUT("/:id", postHandler.Update)
 			adminPosts.DELETE("/:id", postHandler.Delete)
+			adminPosts.POST("/:id/publish", middleware.RequirePermission(models.PermPostsPublish), postHandler.Publish)
 			adminPosts.POST("/preview", postHandler.Preview)
+
+			// 修订历史
+			adminPosts.GET("/:id/revisions", revisionHandler.List)
+			adminPosts.GET("/:id/revisions/diff", revisionHandler.Diff)
+			adminPosts.GET("/:id/revisions/:rid", revisionHandler.Get)
+			adminPosts.POST("/:id/revisions/:rid/restore", revisionHandler.Restore)
+		}
+		// admin：评论审核队列
+		adminComments := r.Group("/api/v1/admin/comments")
+		adminComments.Use(authMW.AuthRequired(), middleware.RequirePermission(models.PermCommentsModerate))
+		{
+			adminComments.GET("", commentHandler.AdminList)
+			adminComments.POST("/moderate", commentHandler.Moderate)
+		}
+		// admin：标签维护
+		adminTags := r.Group("/api/v1/admin/tags")
+		adminTags.Use(authMW.AuthRequired(), middleware.RequirePermission(models.PermTaxonomyManage))
+		{
+			adminTags.PUT("/:id", tagHandler.Rename)
+			adminTags.POST("/:id/merge", tagHandler.Merge)
+			adminTags.DELETE("/unused", tagHandler.DeleteUnused)
+		}
+		// admin：分类维护
+		adminCategories := r.Group("/api/v1/admin/categories")
+		adminCategories.Use(authMW.AuthRequired(), middleware.RequirePermission(models.PermTaxonomyManage))
+		{
+			adminCategories.POST("", categoryHandler.Create)
+			adminCategories.PUT("/:id", categoryHandler.Update)
+			adminCategories.DELETE("/:id", categoryHandler.Delete)
+		}
+		// admin：上传图片/附件
+		adminUploads := r.Group("/api/v1/admin/uploads")
+		adminUploads.Use(authMW.AuthRequired(), middleware.RequirePermission(models.PermMediaUpload))
+		{
+			adminUploads.POST("", uploadHandler.Upload)
+		}
+		// admin：用户管理
+		adminUsers := r.Group("/api/v1/admin/users")
+		adminUsers.Use(authMW.AuthRequired(), middleware.RequirePermission(models.PermUsersManage))
+		{
+			adminUsers.GET("", userHandler.List)
+			adminUsers.GET("/:id", userHandler.Get)
+			adminUsers.PUT("/:id/role", userHandler.ChangeRole)
+			adminUsers.POST("/:id/disable", userHandler.Disable)
+			adminUsers.POST("/:id/enable", userHandler.Enable)
+			adminUsers.POST("/:id/password-reset", userHandler.ForcePasswordReset)
+			adminUsers.DELETE("/:id", userHandler.Delete)
 		}
 	}
 
@@ -2,24 +2,91 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"strings"
 	"time"
 
+	"blog-service/internal/mailer"
 	"blog-service/internal/models"
 	"blog-service/internal/repositories"
 	jwtutil "blog-service/internal/utils/jwt"
 	"blog-service/internal/utils/password"
+	"blog-service/internal/utils/token"
 )
 
 var (
 	ErrInvalidCredentials = errors.New("invalid_credentials")
 	ErrEmailTaken         = errors.New("email_taken")
 	ErrUsernameTaken      = errors.New("username_taken")
+	ErrInvalidResetToken  = errors.New("invalid_reset_token")
+	ErrInvalidRefresh     = errors.New("invalid_refresh_token")
+	ErrAccountDisabled    = errors.New("account_disabled")
+	ErrPasswordResetReq   = errors.New("password_reset_required")
+	ErrAccountLocked      = errors.New("account_locked")
+)
+
+// 密码正确但账号启用了二次验证：凭 Token 调用 VerifyMFA 完成登录
+type MFARequiredError struct {
+	Token     string
+	ExpiresIn time.Duration
+}
+
+func (e *MFARequiredError) Error() string { return "mfa_required" }
+
+// 账号因连续登录失败被临时锁定（当前 IP 或全部 IP）；errors.Is(err, ErrAccountLocked) 为 true
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string { return ErrAccountLocked.Error() }
+
+func (e *AccountLockedError) Is(target error) bool { return target == ErrAccountLocked }
+
+const (
+	passwordResetTTL = 30 * time.Minute
+	// 每个用户：1 分钟内最多 1 封，1 小时内最多 5 封
+	passwordResetMinInterval = time.Minute
+	passwordResetHourlyLimit = 5
+
+	defaultRefreshTTL = 30 * 24 * time.Hour
+
+	// 同一账号在同一 IP 上连续 5 次密码错误后锁定 30 秒，之后每次失败翻倍，最长 1 小时
+	loginLockThreshold = 5
+	loginLockBase      = 30 * time.Second
+	loginLockMax       = time.Hour
+	// 不分 IP 累计 20 次错误后锁定整个账号 1 分钟，同样翻倍退避；阈值较高，避免轻易把本人锁在外面
+	accountLockThreshold = 20
+	accountLockBase      = time.Minute
 )
 
 type AuthService struct {
-	Users *repositories.UserRepo
-	JWT   jwtutil.Manager
+	Users  *repositories.UserRepo
+	JWT    jwtutil.Manager
+	Resets *repositories.PasswordResetRepo
+	Mailer mailer.Mailer
+
+	Refresh    *repositories.RefreshTokenRepo
+	RefreshTTL time.Duration
+	// 登录失败计数（按账号 + IP，以及账号整体）
+	LoginFailures *repositories.LoginFailureRepo
+
+	MFA *repositories.MFARepo
+	// otpauth 链接中显示的服务名
+	MFAIssuer string
+	// 管理员必须启用二次验证（不允许关闭；未绑定时管理权限不生效，见 AuthMiddleware）
+	RequireAdminMFA bool
+
+	// 邮箱验证链接的签名密钥与站点地址
+	VerifySecret []byte
+	BaseURL      string
+}
+
+// 登录/刷新返回的令牌对
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration // access token 有效期
 }
 
 type RegisterInput struct {
@@ -68,6 +135,10 @@ func (s *AuthService) Register(in RegisterInput) (*models.User, error) {
 	if err := s.Users.Create(u); err != nil {
 		return nil, err
 	}
+	// 发送验证邮件
+	if err := s.sendVerification(u, time.Now()); err != nil {
+		log.Printf("send verification mail failed: user=%d err=%v", u.ID, err)
+	}
 	return u, nil
 }
 
@@ -75,30 +146,358 @@ func (s *AuthService) Register(in RegisterInput) (*models.User, error) {
 * 登录
 * @param emailOrUsername 邮箱或用户名
 * @param plainPassword 密码
-* @return token JWT token
+* @param ip 客户端 IP，失败锁定按账号 + IP 与账号整体分别计算
+* @return tokens access token 与 refresh token
 * @return user 用户
 * @return err 错误
  */
-func (s *AuthService) Login(emailOrUsername, plainPassword string) (token string, user *models.User, err error) {
+func (s *AuthService) Login(emailOrUsername, plainPassword, ip string) (tokens *TokenPair, user *models.User, err error) {
 	u, err := s.Users.FindByEmailOrUsername(strings.TrimSpace(emailOrUsername))
 	if err != nil {
 		if repositories.IsNotFound(err) {
-			return "", nil, ErrInvalidCredentials
+			return nil, nil, ErrInvalidCredentials
 		}
-		return "", nil, err
+		return nil, nil, err
+	}
+
+	// 锁定期内直接拒绝，不再校验密码
+	now := time.Now()
+	if err := checkLoginLock(s.LoginFailures, u.ID, ip, now); err != nil {
+		return nil, nil, err
 	}
 
 	// 验证密码
 	if !password.Verify(u.PasswordHash, plainPassword) {
-		return "", nil, ErrInvalidCredentials
+		if err := recordLoginFailure(s.LoginFailures, u.ID, ip, now); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidCredentials
+	}
+	// 密码正确后再提示账号状态，避免泄露账号信息
+	if u.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
+	}
+	if u.PasswordResetRequired {
+		return nil, nil, ErrPasswordResetReq
+	}
+	// 启用了二次验证：先签发挑战，验证码通过后再发正式令牌
+	if u.MFAEnabled() {
+		return nil, nil, s.newMFAChallenge(u, now)
+	}
+
+	// 更新最后登录时间，清零该 IP 与账号整体的失败计数
+	_ = s.Users.UpdateLastLoginAt(u.ID, now)
+	_ = s.LoginFailures.Clear(u.ID, ip)
+
+	tokens, err = s.startSession(u, false, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, u, nil
+}
+
+// 新会话：生成令牌家族并签发第一对 token
+func (s *AuthService) startSession(u *models.User, mfa bool, now time.Time) (*TokenPair, error) {
+	family, _, err := token.Generate(16)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := s.newRefreshToken(u.ID, family, now)
+	if err != nil {
+		return nil, err
+	}
+	refresh.row.MFA = mfa
+	if err := s.Refresh.Create(refresh.row); err != nil {
+		return nil, err
+	}
+	return s.signPair(u, family, refresh.plain, mfa)
+}
+
+// 登录失败计数的存储（*repositories.LoginFailureRepo）
+type loginFailureStore interface {
+	LockedUntil(userID uint, ip string) (*time.Time, error)
+	Inc(userID uint, ip string, now time.Time) (uint, error)
+	SetLockedUntil(userID uint, ip string, until time.Time) error
+}
+
+// 当前 IP 或账号整体处于锁定期时返回 AccountLockedError（取较晚的解锁时间）
+func checkLoginLock(store loginFailureStore, userID uint, ip string, now time.Time) error {
+	var latest *time.Time
+	for _, scope := range []string{ip, models.LoginFailureAnyIP} {
+		until, err := store.LockedUntil(userID, scope)
+		if err != nil {
+			return err
+		}
+		if until != nil && until.After(now) && (latest == nil || until.After(*latest)) {
+			latest = until
+		}
+	}
+	if latest != nil {
+		return &AccountLockedError{Until: *latest}
+	}
+	return nil
+}
+
+// 失败同时计入账号 + IP 与账号整体，各自达到阈值后锁定
+func recordLoginFailure(store loginFailureStore, userID uint, ip string, now time.Time) error {
+	for _, l := range []struct {
+		scope     string
+		threshold uint
+		base      time.Duration
+	}{
+		{ip, loginLockThreshold, loginLockBase},
+		{models.LoginFailureAnyIP, accountLockThreshold, accountLockBase},
+	} {
+		n, err := store.Inc(userID, l.scope, now)
+		if err != nil {
+			return err
+		}
+		if d := lockDuration(n, l.threshold, l.base); d > 0 {
+			if err := store.SetLockedUntil(userID, l.scope, now.Add(d)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 第 n 次失败后的锁定时长：未到阈值为 0，到达阈值为 base，之后每次翻倍，最长 loginLockMax
+func lockDuration(n, threshold uint, base time.Duration) time.Duration {
+	if n < threshold {
+		return 0
+	}
+	lock := base
+	for i := threshold; i < n && lock < loginLockMax; i++ {
+		lock *= 2
+	}
+	return min(lock, loginLockMax)
+}
+
+/*
+* 刷新：校验并轮换 refresh token，签发新的 access token
+* 已使用过的 refresh token 再次出现视为泄露，吊销整个令牌家族
+* @param plainRefresh 客户端持有的 refresh token
+* @return tokens 新的令牌对
+* @return err 错误
+ */
+func (s *AuthService) RefreshTokens(plainRefresh string) (*TokenPair, error) {
+	now := time.Now()
+	old, err := s.Refresh.FindByHash(token.Hash(strings.TrimSpace(plainRefresh)))
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrInvalidRefresh
+		}
+		return nil, err
+	}
+	if old.RevokedAt != nil || !old.ExpiresAt.After(now) {
+		return nil, ErrInvalidRefresh
+	}
+	if old.UsedAt != nil {
+		return nil, s.revokeReused(old, now)
+	}
+
+	u, err := s.Users.FindByID(old.UserID)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrInvalidRefresh
+		}
+		return nil, err
+	}
+	if u.DisabledAt != nil || u.PasswordResetRequired {
+		return nil, ErrInvalidRefresh
 	}
-	// 更新最后登录时间
-	_ = s.Users.UpdateLastLoginAt(u.ID, time.Now())
 
-	// 生成JWT token
-	tok, err := s.JWT.Sign(u.ID, string(u.Role))
+	next, err := s.newRefreshToken(u.ID, old.FamilyID, now)
 	if err != nil {
-		return "", nil, err
+		return nil, err
+	}
+	next.row.MFA = old.MFA
+	if err := s.Refresh.Rotate(old, next.row, now); err != nil {
+		if repositories.IsNotFound(err) {
+			// 并发请求抢先使用了同一个 token
+			return nil, s.revokeReused(old, now)
+		}
+		return nil, err
+	}
+	return s.signPair(u, old.FamilyID, next.plain, old.MFA)
+}
+
+/*
+* 登出：吊销当前 access token 及其所在会话
+* @param claims 当前 access token 的 claims；access token 已过期时为 nil
+* @param plainRefresh 可选，同时吊销该 refresh token 所在家族（带 access token 时需属于同一用户）
+* @return err 错误
+ */
+func (s *AuthService) Logout(claims *jwtutil.Claims, plainRefresh string) error {
+	now := time.Now()
+	sessionID := ""
+	if claims != nil {
+		if claims.ID != "" && claims.ExpiresAt != nil {
+			if err := s.Refresh.RevokeJTI(claims.ID, claims.ExpiresAt.Time); err != nil {
+				return err
+			}
+		}
+		if claims.SessionID != "" {
+			if err := s.Refresh.RevokeFamily(claims.SessionID, now, now.Add(s.JWT.TTL)); err != nil {
+				return err
+			}
+		}
+		sessionID = claims.SessionID
+	}
+	if plainRefresh = strings.TrimSpace(plainRefresh); plainRefresh != "" {
+		t, err := s.Refresh.FindByHash(token.Hash(plainRefresh))
+		if err != nil {
+			if repositories.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		// 持有 refresh token 本身即可证明会话归属
+		if claims != nil && t.UserID != claims.UserID {
+			return nil
+		}
+		if t.FamilyID != sessionID {
+			return s.Refresh.RevokeFamily(t.FamilyID, now, now.Add(s.JWT.TTL))
+		}
+	}
+	return nil
+}
+
+func (s *AuthService) revokeReused(t *models.RefreshToken, now time.Time) error {
+	log.Printf("refresh token reuse detected: user=%d family=%s", t.UserID, t.FamilyID)
+	if err := s.Refresh.RevokeFamily(t.FamilyID, now, now.Add(s.JWT.TTL)); err != nil {
+		return err
+	}
+	return ErrInvalidRefresh
+}
+
+type issuedRefresh struct {
+	plain string
+	row   *models.RefreshToken
+}
+
+func (s *AuthService) newRefreshToken(userID uint, family string, now time.Time) (*issuedRefresh, error) {
+	ttl := s.RefreshTTL
+	if ttl <= 0 {
+		ttl = defaultRefreshTTL
+	}
+	plain, hash, err := token.Generate(32)
+	if err != nil {
+		return nil, err
+	}
+	return &issuedRefresh{
+		plain: plain,
+		row: &models.RefreshToken{
+			UserID:    userID,
+			FamilyID:  family,
+			TokenHash: hash,
+			ExpiresAt: now.Add(ttl),
+		},
+	}, nil
+}
+
+func (s *AuthService) signPair(u *models.User, family, plainRefresh string, mfa bool) (*TokenPair, error) {
+	sign := s.JWT.SignSession
+	if mfa {
+		sign = s.JWT.SignMFASession
+	}
+	access, err := sign(u.ID, string(u.Role), family)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{AccessToken: access, RefreshToken: plainRefresh, ExpiresIn: s.JWT.TTL}, nil
+}
+
+/*
+* 忘记密码：签发重置 token 并发送邮件
+* 为避免枚举账号，邮箱不存在或触发限流时同样返回 nil
+* @param email 邮箱
+* @return err 错误（仅内部错误）
+ */
+func (s *AuthService) ForgotPassword(email string) error {
+	u, err := s.Users.FindByEmail(strings.TrimSpace(strings.ToLower(email)))
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	now := time.Now()
+	plain, hash, err := token.Generate(32)
+	if err != nil {
+		return err
+	}
+	created, err := s.Resets.CreateThrottled(&models.PasswordResetToken{
+		UserID:    u.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(passwordResetTTL),
+	}, now, passwordResetMinInterval, passwordResetHourlyLimit)
+	if err != nil || !created {
+		return err
+	}
+
+	// 异步发信，避免响应耗时暴露账号是否存在
+	go s.sendResetMail(u, plain)
+	return nil
+}
+
+func (s *AuthService) issueResetToken(u *models.User, now time.Time) error {
+	plain, hash, err := token.Generate(32)
+	if err != nil {
+		return err
+	}
+	if err := s.Resets.Create(&models.PasswordResetToken{
+		UserID:    u.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(passwordResetTTL),
+	}); err != nil {
+		return err
+	}
+	s.sendResetMail(u, plain)
+	return nil
+}
+
+// 发送重置邮件；发信失败只记日志，不向调用方暴露
+func (s *AuthService) sendResetMail(u *models.User, plain string) {
+	msg := mailer.Message{
+		To:      u.Email,
+		Subject: "重置密码",
+		Body: fmt.Sprintf("你好 %s：\n\n你的密码重置令牌为：\n%s\n\n请在 %d 分钟内调用 POST /api/v1/auth/password/reset 完成重置。如非本人操作请忽略。",
+			u.Username, plain, int(passwordResetTTL.Minutes())),
+	}
+	if err := s.Mailer.Send(msg); err != nil {
+		log.Printf("send password reset mail failed: user=%d err=%v", u.ID, err)
+	}
+}
+
+/*
+* 重置密码：校验 token、标记已使用并更新密码哈希
+* @param plainToken 邮件中的 token
+* @param newPassword 新密码
+* @return err 错误
+ */
+func (s *AuthService) ResetPassword(plainToken, newPassword string) error {
+	now := time.Now()
+	t, err := s.Resets.FindValidByHash(token.Hash(strings.TrimSpace(plainToken)), now)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	hash, err := password.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Resets.ConsumeAndSetPassword(t, hash, now); err != nil {
+		if repositories.IsNotFound(err) {
+			return ErrInvalidResetToken
+		}
+		return err
 	}
-	return tok, u, nil
+	// 改密后让已登录的会话全部失效
+	return s.Refresh.RevokeAllForUser(t.UserID, now, now.Add(s.JWT.TTL))
 }
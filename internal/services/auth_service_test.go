@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type fakeLoginFailures struct {
+	failures map[string]uint
+	locked   map[string]time.Time
+}
+
+func newFakeLoginFailures() *fakeLoginFailures {
+	return &fakeLoginFailures{failures: map[string]uint{}, locked: map[string]time.Time{}}
+}
+
+func (f *fakeLoginFailures) key(userID uint, ip string) string { return fmt.Sprint(userID, "|", ip) }
+
+func (f *fakeLoginFailures) LockedUntil(userID uint, ip string) (*time.Time, error) {
+	if t, ok := f.locked[f.key(userID, ip)]; ok {
+		return &t, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeLoginFailures) Inc(userID uint, ip string, _ time.Time) (uint, error) {
+	f.failures[f.key(userID, ip)]++
+	return f.failures[f.key(userID, ip)], nil
+}
+
+func (f *fakeLoginFailures) SetLockedUntil(userID uint, ip string, until time.Time) error {
+	f.locked[f.key(userID, ip)] = until
+	return nil
+}
+
+// 每次失败都换一个 IP，单个 IP 的计数永远到不了阈值，但账号整体会被锁定
+func TestLoginLockAcrossIPs(t *testing.T) {
+	store := newFakeLoginFailures()
+	now := time.Unix(1700000000, 0)
+	for i := 0; i < accountLockThreshold; i++ {
+		ip := fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+		if err := checkLoginLock(store, 1, ip, now); err != nil {
+			t.Fatalf("attempt %d: locked too early: %v", i+1, err)
+		}
+		if err := recordLoginFailure(store, 1, ip, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := checkLoginLock(store, 1, "192.0.2.1", now)
+	var locked *AccountLockedError
+	if !errors.As(err, &locked) || !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("want account locked from a new IP, got %v", err)
+	}
+	if want := now.Add(accountLockBase); !locked.Until.Equal(want) {
+		t.Fatalf("locked until %v, want %v", locked.Until, want)
+	}
+	// 其它账号不受影响
+	if err := checkLoginLock(store, 2, "192.0.2.1", now); err != nil {
+		t.Fatalf("other account locked: %v", err)
+	}
+	// 锁定到期后恢复
+	if err := checkLoginLock(store, 1, "192.0.2.1", now.Add(accountLockBase)); err != nil {
+		t.Fatalf("lock should expire: %v", err)
+	}
+}
+
+// 同一 IP 连续失败先触发账号 + IP 锁定，其它 IP 仍可登录
+func TestLoginLockSameIP(t *testing.T) {
+	store := newFakeLoginFailures()
+	now := time.Unix(1700000000, 0)
+	for i := 0; i < loginLockThreshold; i++ {
+		if err := recordLoginFailure(store, 1, "10.0.0.1", now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := checkLoginLock(store, 1, "10.0.0.1", now); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("want locked, got %v", err)
+	}
+	if err := checkLoginLock(store, 1, "10.0.0.2", now); err != nil {
+		t.Fatalf("other IP should not be locked: %v", err)
+	}
+}
+
+func TestLockDuration(t *testing.T) {
+	for _, c := range []struct {
+		n    uint
+		want time.Duration
+	}{
+		{loginLockThreshold - 1, 0},
+		{loginLockThreshold, loginLockBase},
+		{loginLockThreshold + 1, 2 * loginLockBase},
+		{loginLockThreshold + 3, 8 * loginLockBase},
+		{loginLockThreshold + 100, loginLockMax},
+	} {
+		if got := lockDuration(c.n, loginLockThreshold, loginLockBase); got != c.want {
+			t.Errorf("lockDuration(%d) = %v, want %v", c.n, got, c.want)
+		}
+	}
+}
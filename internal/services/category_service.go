@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"blog-service/internal/models"
+	"blog-service/internal/repositories"
+	"blog-service/internal/utils/slug"
+)
+
+var (
+	ErrCategoryNotFound     = errors.New("category_not_found")
+	ErrCategoryNameRequired = errors.New("category_name_required")
+	ErrCategorySlugTaken    = errors.New("category_slug_taken")
+	ErrCategorySlugRequired = errors.New("category_slug_required")
+	ErrCategoryHasChildren  = errors.New("category_has_children")
+	ErrInvalidCategory      = errors.New("invalid_category")
+)
+
+type CategoryService struct {
+	Categories *repositories.CategoryRepo
+	Posts      *repositories.PostRepo
+	// 分类新建、修改、删除时清空 sitemap 缓存，可为 nil
+	Sitemap *SitemapService
+}
+
+type CategoryNode struct {
+	Category  models.Category
+	PostCount int64 // 含子孙分类
+	Children  []*CategoryNode
+}
+
+// 整棵分类树；PostCount 为本分类及子孙分类下的已发布文章数
+func (s *CategoryService) Tree() ([]*CategoryNode, error) {
+	items, err := s.Categories.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.Categories.PublishedPostCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint]*CategoryNode, len(items))
+	for i := range items {
+		nodes[items[i].ID] = &CategoryNode{Category: items[i]}
+	}
+	roots := make([]*CategoryNode, 0)
+	for i := range items {
+		n := nodes[items[i].ID]
+		if pid := items[i].ParentID; pid != nil {
+			if parent, ok := nodes[*pid]; ok {
+				parent.Children = append(parent.Children, n)
+				continue
+			}
+		}
+		roots = append(roots, n)
+	}
+	for _, n := range roots {
+		sumCategoryCounts(n, counts)
+	}
+	return roots, nil
+}
+
+func sumCategoryCounts(n *CategoryNode, counts map[uint]int64) int64 {
+	total := counts[n.Category.ID]
+	for _, child := range n.Children {
+		total += sumCategoryCounts(child, counts)
+	}
+	n.PostCount = total
+	return total
+}
+
+// 分类（含子孙分类）下的已发布文章
+func (s *CategoryService) ListPosts(categorySlug string, page, size int) (*models.Category, []models.Post, int64, error) {
+	c, err := s.Categories.FindBySlug(categorySlug)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, nil, 0, ErrCategoryNotFound
+		}
+		return nil, nil, 0, err
+	}
+	ids, err := s.descendantIDs(c.ID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	items, total, err := s.Posts.ListPublishedByCategories(ids, page, size)
+	return c, items, total, err
+}
+
+type CategoryInput struct {
+	Name        *string
+	Slug        *string
+	Description *string
+	ParentID    *uint // 更新时传 0 表示移到顶层
+	SortOrder   *int
+}
+
+func (s *CategoryService) Create(in CategoryInput) (*models.Category, error) {
+	c := &models.Category{}
+	if in.Name == nil {
+		return nil, ErrCategoryNameRequired
+	}
+	if err := s.apply(c, in); err != nil {
+		return nil, err
+	}
+	if err := s.Categories.Create(c); err != nil {
+		return nil, err
+	}
+	s.Sitemap.Invalidate()
+	return c, nil
+}
+
+func (s *CategoryService) Update(id uint, in CategoryInput) (*models.Category, error) {
+	c, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(c, in); err != nil {
+		return nil, err
+	}
+	if err := s.Categories.Update(c); err != nil {
+		return nil, err
+	}
+	s.Sitemap.Invalidate()
+	return c, nil
+}
+
+// 有子分类时拒绝删除；文章的 category_id 由外键置空
+func (s *CategoryService) Delete(id uint) error {
+	if _, err := s.find(id); err != nil {
+		return err
+	}
+	n, err := s.Categories.CountChildren(id)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrCategoryHasChildren
+	}
+	if err := s.Categories.DeleteByID(id); err != nil {
+		return err
+	}
+	s.Sitemap.Invalidate()
+	return nil
+}
+
+func (s *CategoryService) apply(c *models.Category, in CategoryInput) error {
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		if name == "" {
+			return ErrCategoryNameRequired
+		}
+		c.Name = name
+	}
+
+	if in.Slug != nil || c.Slug == "" {
+		src := c.Name
+		if in.Slug != nil && strings.TrimSpace(*in.Slug) != "" {
+			src = *in.Slug
+		}
+		// 名称无法生成 slug（如纯中文）时要求显式指定，避免都落到同一个默认值
+		sl := slug.Normalize(src)
+		if sl == "" {
+			return ErrCategorySlugRequired
+		}
+		if sl != c.Slug {
+			exists, err := s.Categories.SlugExists(sl)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return ErrCategorySlugTaken
+			}
+			c.Slug = sl
+		}
+	}
+
+	if in.Description != nil {
+		c.Description = strings.TrimSpace(*in.Description)
+	}
+	if in.SortOrder != nil {
+		c.SortOrder = *in.SortOrder
+	}
+
+	if in.ParentID != nil {
+		if *in.ParentID == 0 {
+			c.ParentID = nil
+		} else {
+			if err := s.checkParent(c.ID, *in.ParentID); err != nil {
+				return err
+			}
+			pid := *in.ParentID
+			c.ParentID = &pid
+		}
+	}
+	return nil
+}
+
+// 父分类必须存在，且不能是自己或自己的子孙（避免成环）
+func (s *CategoryService) checkParent(selfID, parentID uint) error {
+	if _, err := s.Categories.FindByID(parentID); err != nil {
+		if repositories.IsNotFound(err) {
+			return ErrInvalidParent
+		}
+		return err
+	}
+	if selfID == 0 {
+		return nil
+	}
+	ids, err := s.descendantIDs(selfID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if id == parentID {
+			return ErrInvalidParent
+		}
+	}
+	return nil
+}
+
+// 返回 id 本身及全部子孙分类 id
+func (s *CategoryService) descendantIDs(id uint) ([]uint, error) {
+	items, err := s.Categories.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	children := map[uint][]uint{}
+	for _, c := range items {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+
+	out := []uint{id}
+	seen := map[uint]bool{id: true}
+	for i := 0; i < len(out); i++ {
+		for _, child := range children[out[i]] {
+			if !seen[child] {
+				seen[child] = true
+				out = append(out, child)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *CategoryService) find(id uint) (*models.Category, error) {
+	c, err := s.Categories.FindByID(id)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	return c, nil
+}
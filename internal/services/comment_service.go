@@ -0,0 +1,228 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"blog-service/internal/models"
+	"blog-service/internal/repositories"
+)
+
+var (
+	ErrCommentNotFound  = errors.New("comment_not_found")
+	ErrCommentForbidden = errors.New("forbidden")
+	ErrInvalidParent    = errors.New("invalid_parent")
+	ErrInvalidAction    = errors.New("invalid_action")
+)
+
+// 审核动作 -> 目标状态
+var moderationActions = map[string]models.CommentStatus{
+	"approve": models.CommentApproved,
+	"reject":  models.CommentRejected,
+	"spam":    models.CommentSpam,
+}
+
+type CommentService struct {
+	Comments *repositories.CommentRepo
+	Posts    *repositories.PostRepo
+
+	// 开启后普通用户的新评论进入 pending，需管理员审核
+	Moderation bool
+}
+
+// 评论树节点：已删除但仍有回复的评论保留为占位节点
+type CommentNode struct {
+	Comment models.Comment
+	Deleted bool
+	Replies []*CommentNode
+}
+
+type CreateCommentInput struct {
+	PostSlug string
+	AuthorID uint
+	ParentID *uint
+	Content  string
+	IsAdmin  bool
+}
+
+func (s *CommentService) Create(in CreateCommentInput) (*models.Comment, error) {
+	content := strings.TrimSpace(in.Content)
+	if content == "" {
+		return nil, ErrContentRequired
+	}
+
+	p, err := s.findPost(in.PostSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	// 回复必须挂在同一篇文章下的有效评论上
+	if in.ParentID != nil {
+		parent, err := s.Comments.FindByID(*in.ParentID)
+		if err != nil {
+			if repositories.IsNotFound(err) {
+				return nil, ErrInvalidParent
+			}
+			return nil, err
+		}
+		if parent.PostID != p.ID || parent.Status != models.CommentApproved {
+			return nil, ErrInvalidParent
+		}
+	}
+
+	cm := &models.Comment{
+		PostID:   p.ID,
+		AuthorID: in.AuthorID,
+		ParentID: in.ParentID,
+		Content:  content,
+		Status:   s.initialStatus(in.IsAdmin),
+	}
+	if err := s.Comments.Create(cm); err != nil {
+		return nil, err
+	}
+	return s.Comments.FindByID(cm.ID)
+}
+
+// 按 ParentID 组装评论树
+func (s *CommentService) ListTree(postSlug string) ([]*CommentNode, error) {
+	p, err := s.findPost(postSlug)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.Comments.ListApprovedByPost(p.ID)
+	if err != nil {
+		return nil, err
+	}
+	return buildCommentTree(items), nil
+}
+
+type UpdateCommentInput struct {
+	PostSlug  string
+	CommentID uint
+	UserID    uint
+	IsAdmin   bool
+	Content   string
+}
+
+func (s *CommentService) Update(in UpdateCommentInput) (*models.Comment, error) {
+	content := strings.TrimSpace(in.Content)
+	if content == "" {
+		return nil, ErrContentRequired
+	}
+
+	cm, err := s.findOwnComment(in.PostSlug, in.CommentID, in.UserID, in.IsAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	// 审核开启时，普通用户修改后需重新审核
+	status := cm.Status
+	if s.Moderation && !in.IsAdmin {
+		status = models.CommentPending
+	}
+	if err := s.Comments.UpdateContent(cm, content, status); err != nil {
+		return nil, err
+	}
+	return s.Comments.FindByID(cm.ID)
+}
+
+func (s *CommentService) Delete(postSlug string, commentID, userID uint, isAdmin bool) error {
+	cm, err := s.findOwnComment(postSlug, commentID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	return s.Comments.SoftDelete(cm)
+}
+
+func (s *CommentService) ListForModeration(f repositories.CommentFilter, page, size int) ([]models.Comment, int64, error) {
+	return s.Comments.ListForModeration(f, page, size)
+}
+
+// 批量审核：approve / reject / spam
+func (s *CommentService) Moderate(ids []uint, action string) (int64, error) {
+	status, ok := moderationActions[action]
+	if !ok {
+		return 0, ErrInvalidAction
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return s.Comments.BulkUpdateStatus(ids, status)
+}
+
+func (s *CommentService) initialStatus(isAdmin bool) models.CommentStatus {
+	if s.Moderation && !isAdmin {
+		return models.CommentPending
+	}
+	return models.CommentApproved
+}
+
+func (s *CommentService) findPost(postSlug string) (*models.Post, error) {
+	p, err := s.Posts.FindBySlugPublished(postSlug)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// 查找评论并校验归属：仅作者本人或管理员可操作
+func (s *CommentService) findOwnComment(postSlug string, commentID, userID uint, isAdmin bool) (*models.Comment, error) {
+	p, err := s.findPost(postSlug)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := s.Comments.FindByID(commentID)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+	if cm.PostID != p.ID {
+		return nil, ErrCommentNotFound
+	}
+	if !isAdmin && cm.AuthorID != userID {
+		return nil, ErrCommentForbidden
+	}
+	return cm, nil
+}
+
+func buildCommentTree(items []models.Comment) []*CommentNode {
+	nodes := make(map[uint]*CommentNode, len(items))
+	for i := range items {
+		nodes[items[i].ID] = &CommentNode{
+			Comment: items[i],
+			Deleted: items[i].DeletedAt != nil,
+		}
+	}
+
+	roots := make([]*CommentNode, 0)
+	for i := range items {
+		n := nodes[items[i].ID]
+		if pid := items[i].ParentID; pid != nil {
+			if parent, ok := nodes[*pid]; ok {
+				parent.Replies = append(parent.Replies, n)
+				continue
+			}
+		}
+		// 父评论不可见（未通过等）时提升为顶层
+		roots = append(roots, n)
+	}
+	return pruneDeleted(roots)
+}
+
+// 去掉没有任何可见回复的已删除节点
+func pruneDeleted(nodes []*CommentNode) []*CommentNode {
+	out := nodes[:0]
+	for _, n := range nodes {
+		n.Replies = pruneDeleted(n.Replies)
+		if n.Deleted && len(n.Replies) == 0 {
+			continue
+		}
+		out = append(out, n)
+	}
+	return out
+}
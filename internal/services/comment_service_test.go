@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"blog-service/internal/models"
+)
+
+func comment(id uint, parent uint, deleted bool) models.Comment {
+	c := models.Comment{ID: id}
+	if parent != 0 {
+		c.ParentID = &parent
+	}
+	if deleted {
+		now := time.Now()
+		c.DeletedAt = &now
+	}
+	return c
+}
+
+// 以 "id(回复...)" 形式输出评论树，已删除节点带 x 前缀
+func treeString(nodes []*CommentNode) string {
+	parts := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		s := fmt.Sprint(n.Comment.ID)
+		if n.Deleted {
+			s = "x" + s
+		}
+		if len(n.Replies) > 0 {
+			s += "(" + treeString(n.Replies) + ")"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestBuildCommentTree(t *testing.T) {
+	cases := []struct {
+		name  string
+		items []models.Comment
+		want  string
+	}{
+		{"empty", nil, ""},
+		{"flat", []models.Comment{comment(1, 0, false), comment(2, 0, false)}, "1 2"},
+		{
+			"nested depth",
+			[]models.Comment{comment(1, 0, false), comment(2, 1, false), comment(3, 2, false), comment(4, 3, false)},
+			"1(2(3(4)))",
+		},
+		{
+			"child listed before parent",
+			[]models.Comment{comment(2, 1, false), comment(1, 0, false)},
+			"1(2)",
+		},
+		{
+			// 父评论不在列表中（未通过审核等）时提升为顶层
+			"orphan promoted",
+			[]models.Comment{comment(1, 0, false), comment(3, 99, false)},
+			"1 3",
+		},
+		{
+			"deleted parent with live child kept",
+			[]models.Comment{comment(1, 0, true), comment(2, 1, false)},
+			"x1(2)",
+		},
+		{
+			"deleted leaf pruned",
+			[]models.Comment{comment(1, 0, false), comment(2, 1, true), comment(3, 0, true)},
+			"1",
+		},
+		{
+			// 整条链都已删除时全部去掉
+			"deleted chain pruned",
+			[]models.Comment{comment(1, 0, true), comment(2, 1, true), comment(3, 2, true)},
+			"",
+		},
+		{
+			"deleted ancestors kept for deep live reply",
+			[]models.Comment{comment(1, 0, true), comment(2, 1, true), comment(3, 2, false), comment(4, 1, true)},
+			"x1(x2(3))",
+		},
+	}
+	for _, c := range cases {
+		if got := treeString(buildCommentTree(c.items)); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
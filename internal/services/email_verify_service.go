@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog-service/internal/mailer"
+	"blog-service/internal/models"
+	"blog-service/internal/repositories"
+	"blog-service/internal/utils/token"
+)
+
+var (
+	ErrInvalidVerifyLink    = errors.New("invalid_verification_link")
+	ErrEmailAlreadyVerified = errors.New("email_already_verified")
+	ErrVerifyTooSoon        = errors.New("verification_resend_too_soon")
+)
+
+// 距上次发送时间太短；errors.Is(err, ErrVerifyTooSoon) 为 true
+type VerifyTooSoonError struct {
+	Until time.Time
+}
+
+func (e *VerifyTooSoonError) Error() string { return ErrVerifyTooSoon.Error() }
+
+func (e *VerifyTooSoonError) Is(target error) bool { return target == ErrVerifyTooSoon }
+
+const (
+	emailVerifyTTL = 24 * time.Hour
+	// 重发间隔下限；每小时上限由路由限流控制
+	emailVerifyMinInterval = 2 * time.Minute
+)
+
+/*
+* 校验邮件中的验证链接并标记邮箱已验证；重复点击同一链接视为成功
+* @param uid 链接中的用户 ID
+* @param exp 链接过期时间（unix 秒）
+* @param sig 签名
+ */
+func (s *AuthService) VerifyEmail(uid uint, exp int64, sig string) (*models.User, error) {
+	now := time.Now()
+	if exp <= now.Unix() {
+		return nil, ErrInvalidVerifyLink
+	}
+	u, err := s.Users.FindByID(uid)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrInvalidVerifyLink
+		}
+		return nil, err
+	}
+	// 签名覆盖邮箱：邮箱变更后旧链接失效
+	if !token.VerifySignature(s.VerifySecret, verifyMessage(u, exp), sig) {
+		return nil, ErrInvalidVerifyLink
+	}
+	if u.EmailVerified() {
+		return u, nil
+	}
+	if err := s.Users.MarkEmailVerified(u.ID, now); err != nil {
+		return nil, err
+	}
+	u.EmailVerifiedAt = &now
+	return u, nil
+}
+
+/*
+* 重发验证邮件
+* @param userID 当前用户
+ */
+func (s *AuthService) ResendVerification(userID uint) error {
+	u, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+	if u.EmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+	now := time.Now()
+	if u.VerificationSentAt != nil {
+		if next := u.VerificationSentAt.Add(emailVerifyMinInterval); next.After(now) {
+			return &VerifyTooSoonError{Until: next}
+		}
+	}
+	return s.sendVerification(u, now)
+}
+
+// 发送验证邮件；只有记录发送时间失败时返回错误（此时不发信），发信失败只记日志
+func (s *AuthService) sendVerification(u *models.User, now time.Time) error {
+	if err := s.Users.SetVerificationSentAt(u.ID, now); err != nil {
+		return fmt.Errorf("record sent time: %w", err)
+	}
+	exp := now.Add(emailVerifyTTL).Unix()
+	q := url.Values{}
+	q.Set("uid", strconv.FormatUint(uint64(u.ID), 10))
+	q.Set("exp", strconv.FormatInt(exp, 10))
+	q.Set("sig", token.Sign(s.VerifySecret, verifyMessage(u, exp)))
+	link := strings.TrimRight(s.BaseURL, "/") + "/api/v1/auth/email/verify?" + q.Encode()
+
+	msg := mailer.Message{
+		To:      u.Email,
+		Subject: "验证邮箱",
+		Body: fmt.Sprintf("你好 %s：\n\n请在 %d 小时内点击以下链接验证邮箱：\n%s\n\n如非本人操作请忽略。",
+			u.Username, int(emailVerifyTTL.Hours()), link),
+	}
+	if err := s.Mailer.Send(msg); err != nil {
+		log.Printf("send verification mail failed: user=%d err=%v", u.ID, err)
+	}
+	return nil
+}
+
+func verifyMessage(u *models.User, exp int64) string {
+	return fmt.Sprintf("verify-email\n%d\n%s\n%d", u.ID, u.Email, exp)
+}
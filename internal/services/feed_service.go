@@ -0,0 +1,119 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+
+	"blog-service/internal/feed"
+	"blog-service/internal/models"
+	"blog-service/internal/repositories"
+)
+
+// 订阅源默认条数
+const defaultFeedLimit = 20
+
+// RSS / Atom / JSON Feed 订阅源；链接均基于 BaseURL 生成绝对地址
+type FeedService struct {
+	Posts *repositories.PostRepo
+	Tags  *repositories.TagRepo
+	Users *repositories.UserRepo
+
+	BaseURL     string
+	Title       string
+	Description string
+	Language    string
+	Limit       int
+}
+
+// 订阅范围：Tag、Author 都为空时为全站
+type FeedScope struct {
+	Tag    string
+	Author string // 用户名
+}
+
+/*
+* 生成订阅源（与输出格式无关）
+* @param scope 订阅范围
+* @param selfPath 订阅源自身的路径，如 /tags/go/atom.xml
+ */
+func (s *FeedService) Build(scope FeedScope, selfPath string) (*feed.Feed, error) {
+	base := strings.TrimRight(s.BaseURL, "/")
+	f := &feed.Feed{
+		Title:       s.Title,
+		Description: s.Description,
+		SiteURL:     base + "/",
+		FeedURL:     base + selfPath,
+		Language:    s.Language,
+	}
+
+	var filter repositories.FeedFilter
+	if scope.Tag != "" {
+		t, err := s.Tags.FindByName(scope.Tag)
+		if err != nil {
+			if repositories.IsNotFound(err) {
+				return nil, ErrTagNotFound
+			}
+			return nil, err
+		}
+		filter.TagName = t.Name
+		f.Title = s.Title + " - #" + t.Name
+		f.SiteURL = base + "/tags/" + url.PathEscape(t.Name)
+	}
+	if scope.Author != "" {
+		u, err := s.Users.FindByUsername(scope.Author)
+		if err != nil {
+			if repositories.IsNotFound(err) {
+				return nil, ErrUserNotFound
+			}
+			return nil, err
+		}
+		filter.AuthorID = u.ID
+		f.Title = s.Title + " - " + u.Username
+	}
+
+	limit := s.Limit
+	if limit <= 0 {
+		limit = defaultFeedLimit
+	}
+	posts, err := s.Posts.ListFeed(filter, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	host := base
+	if u, err := url.Parse(base); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	for _, p := range posts {
+		it := s.feedItem(p, base, host)
+		if it.Updated.After(f.Updated) {
+			f.Updated = it.Updated
+		}
+		f.Items = append(f.Items, it)
+	}
+	return f, nil
+}
+
+func (s *FeedService) feedItem(p models.Post, base, host string) feed.Item {
+	it := feed.Item{
+		// id 用创建时间 + 文章 id，修改 slug 后阅读器不会当成新文章
+		ID:      feed.TagURI(host, p.CreatedAt, "post", p.ID),
+		Title:   p.Title,
+		URL:     base + "/posts/" + url.PathEscape(p.Slug),
+		HTML:    feed.AbsoluteURLs(p.ContentHTML, base),
+		Author:  p.Author.Username,
+		Updated: p.UpdatedAt,
+	}
+	it.Published = p.CreatedAt
+	if p.PublishedAt != nil {
+		it.Published = *p.PublishedAt
+	}
+	// 定时发布的文章 updated_at 可能早于发布时间
+	if it.Published.After(it.Updated) {
+		it.Updated = it.Published
+	}
+	for _, t := range p.Tags {
+		it.Tags = append(it.Tags, t.Name)
+	}
+	return it
+}
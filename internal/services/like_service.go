@@ -0,0 +1,49 @@
+package services
+
+import (
+	"blog-service/internal/models"
+	"blog-service/internal/repositories"
+)
+
+type LikeService struct {
+	Likes *repositories.LikeRepo
+	Posts *repositories.PostRepo
+}
+
+// 点赞/取消点赞后返回最新的文章（含 like_count）
+func (s *LikeService) Like(postSlug string, userID uint) (*models.Post, error) {
+	p, err := s.findPublished(postSlug)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Likes.Like(p.ID, userID); err != nil {
+		return nil, err
+	}
+	return s.Posts.FindByID(p.ID)
+}
+
+func (s *LikeService) Unlike(postSlug string, userID uint) (*models.Post, error) {
+	p, err := s.findPublished(postSlug)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Likes.Unlike(p.ID, userID); err != nil {
+		return nil, err
+	}
+	return s.Posts.FindByID(p.ID)
+}
+
+func (s *LikeService) ListLikedPosts(userID uint, page, size int) ([]models.Post, int64, error) {
+	return s.Likes.ListLikedPosts(userID, page, size)
+}
+
+func (s *LikeService) findPublished(postSlug string) (*models.Post, error) {
+	p, err := s.Posts.FindBySlugPublished(postSlug)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
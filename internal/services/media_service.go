@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	"blog-service/internal/models"
+	"blog-service/internal/repositories"
+	"blog-service/internal/storage"
+)
+
+var (
+	ErrFileTooLarge    = errors.New("file_too_large")
+	ErrUnsupportedType = errors.New("unsupported_media_type")
+	ErrEmptyFile       = errors.New("empty_file")
+)
+
+const defaultUploadMaxBytes = 10 << 20
+
+// 允许上传的类型（按内容嗅探结果判断，不信任扩展名/Content-Type 头）
+var allowedMediaTypes = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+type MediaService struct {
+	Media    *repositories.MediaRepo
+	Storage  storage.Storage
+	MaxBytes int64
+}
+
+// 上传文件：先落临时文件同时计算哈希，再按内容寻址写入存储后端；
+// 返回的 created=false 表示命中已有文件（去重）
+func (s *MediaService) Upload(ctx context.Context, r io.Reader, originalName string, uploaderID uint) (m *models.Media, created bool, err error) {
+	maxBytes := s.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultUploadMaxBytes
+	}
+
+	tmp, err := os.CreateTemp("", "blog-upload-*")
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	// 多读 1 字节用来判断是否超限
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if n == 0 {
+		return nil, false, ErrEmptyFile
+	}
+	if n > maxBytes {
+		return nil, false, ErrFileTooLarge
+	}
+
+	head := make([]byte, 512)
+	hn, err := tmp.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, false, err
+	}
+	mimeType := http.DetectContentType(head[:hn])
+	ext, ok := allowedMediaTypes[mimeType]
+	if !ok {
+		return nil, false, ErrUnsupportedType
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	if existing, err := s.Media.FindByHash(hash); err == nil {
+		return existing, false, nil
+	} else if !repositories.IsNotFound(err) {
+		return nil, false, err
+	}
+
+	// ab/cd/abcdef....png
+	key := path.Join(hash[0:2], hash[2:4], hash+ext)
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+	if err := s.Storage.Put(ctx, key, tmp, n, mimeType); err != nil {
+		return nil, false, err
+	}
+
+	m = &models.Media{
+		Hash:         hash,
+		Path:         key,
+		MimeType:     mimeType,
+		Size:         n,
+		OriginalName: truncate(filepath.Base(originalName), 255),
+		UploaderID:   uploaderID,
+	}
+	created, err = s.Media.Create(m)
+	if err != nil {
+		return nil, false, err
+	}
+	if !created {
+		// 并发上传同一文件撞唯一键：返回已存在的记录（对象内容相同，覆盖无害）
+		existing, err := s.Media.FindByHash(hash)
+		if err != nil {
+			return nil, false, err
+		}
+		return existing, false, nil
+	}
+	return m, true, nil
+}
+
+// 对外访问地址（由存储后端决定）
+func (s *MediaService) URL(m *models.Media) string {
+	return s.Storage.URL(m.Path)
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
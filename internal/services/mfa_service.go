@@ -0,0 +1,317 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"blog-service/internal/models"
+	"blog-service/internal/repositories"
+	"blog-service/internal/utils/password"
+	"blog-service/internal/utils/token"
+	"blog-service/internal/utils/totp"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("mfa_already_enabled")
+	ErrMFANotEnabled     = errors.New("mfa_not_enabled")
+	ErrMFANotSetup       = errors.New("mfa_not_setup")
+	ErrMFARequired       = errors.New("mfa_required")
+	ErrInvalidMFACode    = errors.New("invalid_mfa_code")
+	ErrInvalidMFAToken   = errors.New("invalid_mfa_token")
+)
+
+const (
+	mfaChallengeTTL = 5 * time.Minute
+	// 单个 mfa_token 允许的验证码错误次数
+	mfaChallengeMaxAttempts = 5
+	// 允许前后各一个步长（30 秒）的时钟偏差
+	totpSkew = 1
+
+	recoveryCodeCount = 10
+	recoveryCodeLen   = 10
+	defaultMFAIssuer  = "Blog Service"
+)
+
+// 绑定第一步返回：密钥与 otpauth 链接（前端渲染成二维码）
+type MFASetup struct {
+	Secret string
+	URI    string
+}
+
+/*
+* 开始绑定 TOTP：生成新密钥，尚未启用；重复调用会覆盖未确认的密钥
+* @param userID 当前用户
+ */
+func (s *AuthService) SetupMFA(userID uint) (*MFASetup, error) {
+	u, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.MFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.MFA.SetPendingSecret(u.ID, secret); err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrMFAAlreadyEnabled
+		}
+		return nil, err
+	}
+	issuer := s.MFAIssuer
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+	return &MFASetup{Secret: secret, URI: totp.URI(issuer, u.Email, secret)}, nil
+}
+
+/*
+* 确认绑定：校验验证码后启用，返回一次性恢复码（只展示这一次）
+* 已有会话全部吊销，当前客户端改用返回的带 mfa 标记的新令牌
+* @param code 验证器上的 6 位验证码
+ */
+func (s *AuthService) EnableMFA(userID uint, code string) (recoveryCodes []string, tokens *TokenPair, err error) {
+	u, err := s.findUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.MFAEnabled() {
+		return nil, nil, ErrMFAAlreadyEnabled
+	}
+	if u.TOTPSecret == "" {
+		return nil, nil, ErrMFANotSetup
+	}
+	now := time.Now()
+	step, ok := totp.Validate(u.TOTPSecret, code, now, totpSkew)
+	if !ok {
+		return nil, nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.MFA.Enable(u.ID, step, hashes, now); err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, nil, ErrMFAAlreadyEnabled
+		}
+		return nil, nil, err
+	}
+	if err := s.Refresh.RevokeAllForUser(u.ID, now, now.Add(s.JWT.TTL)); err != nil {
+		return nil, nil, err
+	}
+	tokens, err = s.startSession(u, true, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return codes, tokens, nil
+}
+
+/*
+* 关闭二次验证：需要当前密码和一个有效验证码（或恢复码）
+* 配置要求管理员启用时，管理员不能关闭
+ */
+func (s *AuthService) DisableMFA(userID uint, plainPassword, code string) error {
+	u, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+	if !u.MFAEnabled() {
+		return ErrMFANotEnabled
+	}
+	if s.RequireAdminMFA && u.Role == models.RoleAdmin {
+		return ErrMFARequired
+	}
+	if !password.Verify(u.PasswordHash, plainPassword) {
+		return ErrInvalidCredentials
+	}
+	if err := s.checkMFACode(u, code, time.Now()); err != nil {
+		return err
+	}
+	return s.MFA.Disable(u.ID)
+}
+
+/*
+* 重新生成恢复码，旧恢复码全部作废
+* @param code 验证器上的 6 位验证码（不接受恢复码）
+ */
+func (s *AuthService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	u, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !u.MFAEnabled() {
+		return nil, ErrMFANotEnabled
+	}
+	if err := s.checkTOTP(u, code, time.Now()); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.MFA.ReplaceRecoveryCodes(u.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// 二次验证状态（用于 /auth/me）
+func (s *AuthService) MFAStatus(u *models.User) (enabled bool, recoveryCodesLeft int64, err error) {
+	if !u.MFAEnabled() {
+		return false, 0, nil
+	}
+	n, err := s.MFA.CountUnusedRecoveryCodes(u.ID)
+	return true, n, err
+}
+
+/*
+* 两步登录第二步：用 mfa_token + 验证码（或恢复码）换取正式令牌
+* 验证码错误计入账号在该 IP 上的登录失败次数，与密码错误共用锁定策略
+* @param ip 客户端 IP
+ */
+func (s *AuthService) VerifyMFA(mfaToken, code, ip string) (*TokenPair, *models.User, error) {
+	now := time.Now()
+	ch, err := s.MFA.FindValidChallenge(token.Hash(strings.TrimSpace(mfaToken)), now)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, nil, ErrInvalidMFAToken
+		}
+		return nil, nil, err
+	}
+	u, err := s.Users.FindByID(ch.UserID)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, nil, ErrInvalidMFAToken
+		}
+		return nil, nil, err
+	}
+	if u.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
+	}
+	if !u.MFAEnabled() {
+		return nil, nil, ErrInvalidMFAToken
+	}
+	if err := checkLoginLock(s.LoginFailures, u.ID, ip, now); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.checkMFACode(u, code, now); err != nil {
+		if err != ErrInvalidMFACode {
+			return nil, nil, err
+		}
+		if err := s.MFA.FailChallenge(ch.ID, mfaChallengeMaxAttempts, now); err != nil {
+			return nil, nil, err
+		}
+		if err := recordLoginFailure(s.LoginFailures, u.ID, ip, now); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidMFACode
+	}
+	if err := s.MFA.ConsumeChallenge(ch.ID, now); err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, nil, ErrInvalidMFAToken
+		}
+		return nil, nil, err
+	}
+
+	_ = s.Users.UpdateLastLoginAt(u.ID, now)
+	_ = s.LoginFailures.Clear(u.ID, ip)
+	tokens, err := s.startSession(u, true, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, u, nil
+}
+
+func (s *AuthService) newMFAChallenge(u *models.User, now time.Time) error {
+	plain, hash, err := token.Generate(32)
+	if err != nil {
+		return err
+	}
+	if err := s.MFA.CreateChallenge(&models.MFAChallenge{
+		UserID:    u.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(mfaChallengeTTL),
+	}); err != nil {
+		return err
+	}
+	return &MFARequiredError{Token: plain, ExpiresIn: mfaChallengeTTL}
+}
+
+// 6 位数字按 TOTP 校验，其它按恢复码校验
+func (s *AuthService) checkMFACode(u *models.User, code string, now time.Time) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits && strings.Trim(code, "0123456789") == "" {
+		return s.checkTOTP(u, code, now)
+	}
+	if err := s.MFA.UseRecoveryCode(u.ID, token.Hash(normalizeRecoveryCode(code)), now); err != nil {
+		if repositories.IsNotFound(err) {
+			return ErrInvalidMFACode
+		}
+		return err
+	}
+	return nil
+}
+
+// 校验 TOTP 并记录步数，同一验证码只能用一次
+func (s *AuthService) checkTOTP(u *models.User, code string, now time.Time) error {
+	step, ok := totp.Validate(u.TOTPSecret, code, now, totpSkew)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	fresh, err := s.MFA.AdvanceTOTPStep(u.ID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *AuthService) findUser(id uint) (*models.User, error) {
+	u, err := s.Users.FindByID(id)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+// 恢复码字母表：去掉易混淆的 0/1/i/l/o
+const recoveryAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// 生成一组恢复码，形如 "abcde-fghjk"；返回明文与入库哈希
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	buf := make([]byte, recoveryCodeLen)
+	for i := 0; i < recoveryCodeCount; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		var b strings.Builder
+		for j, c := range buf {
+			if j == recoveryCodeLen/2 {
+				b.WriteByte('-')
+			}
+			// 31 个字符取模有轻微偏差，50 位熵下可忽略
+			b.WriteByte(recoveryAlphabet[int(c)%len(recoveryAlphabet)])
+		}
+		code := b.String()
+		codes = append(codes, code)
+		hashes = append(hashes, token.Hash(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+// 忽略大小写、空格与连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
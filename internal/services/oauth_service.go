@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"blog-service/internal/models"
+	"blog-service/internal/oauth"
+	"blog-service/internal/repositories"
+	"blog-service/internal/utils/slug"
+	"blog-service/internal/utils/token"
+)
+
+var (
+	ErrUnknownProvider     = errors.New("unknown_provider")
+	ErrInvalidOAuthState   = errors.New("invalid_oauth_state")
+	ErrOAuthFailed         = errors.New("oauth_failed")
+	ErrOAuthEmailRequired  = errors.New("oauth_email_required")
+	ErrIdentityNotFound    = errors.New("identity_not_found")
+	ErrLastLoginMethod     = errors.New("last_login_method")
+	errUsernameUnavailable = errors.New("no available username")
+)
+
+// 授权流程的有效期：从跳转到回调
+const oauthStateTTL = 10 * time.Minute
+
+// 第三方登录：授权码 + PKCE，登录成功后签发与密码登录相同的令牌
+type OAuthService struct {
+	Auth       *AuthService
+	Users      *repositories.UserRepo
+	Identities *repositories.IdentityRepo
+	Providers  map[string]oauth.Provider
+	// state cookie 的签名密钥
+	StateSecret []byte
+}
+
+// 已启用的提供方名称
+func (s *OAuthService) ProviderNames() []string {
+	names := make([]string, 0, len(s.Providers))
+	for name := range s.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 授权流程中间态：存在签名 cookie 里，回调时校验，服务端无需存储
+type oauthState struct {
+	Provider string `json:"p"`
+	State    string `json:"s"`
+	Nonce    string `json:"n"`
+	Verifier string `json:"v"`
+	Expires  int64  `json:"e"`
+}
+
+/*
+* 开始第三方登录
+* @param provider 提供方名称
+* @return authURL 跳转到提供方的授权地址
+* @return cookie 需写入浏览器的 state cookie，回调时原样带回
+ */
+func (s *OAuthService) Begin(ctx context.Context, provider string) (authURL, cookie string, err error) {
+	p, ok := s.Providers[provider]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+	state, _, err := token.Generate(16)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, _, err := token.Generate(16)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, challenge, err := oauth.NewPKCE()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err = p.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		log.Printf("oauth %s: build auth url failed: %v", provider, err)
+		return "", "", ErrOAuthFailed
+	}
+	cookie, err = s.encodeState(oauthState{
+		Provider: provider,
+		State:    state,
+		Nonce:    nonce,
+		Verifier: verifier,
+		Expires:  time.Now().Add(oauthStateTTL).Unix(),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, cookie, nil
+}
+
+/*
+* 授权回调：校验 state，换取外部身份并登录（必要时自动注册/绑定）
+* 外部邮箱已验证且与本站账号一致时自动绑定；未验证时拒绝，防止借邮箱接管账号
+* @param cookie Begin 返回的 state cookie
+* @param state 回调参数 state
+* @param code 回调参数 code
+ */
+func (s *OAuthService) Complete(ctx context.Context, provider, cookie, state, code string) (*TokenPair, *models.User, error) {
+	p, ok := s.Providers[provider]
+	if !ok {
+		return nil, nil, ErrUnknownProvider
+	}
+	st, err := s.decodeState(cookie)
+	if err != nil || st.Provider != provider || st.State == "" || st.State != state || code == "" {
+		return nil, nil, ErrInvalidOAuthState
+	}
+
+	id, err := p.Identify(ctx, code, st.Verifier, st.Nonce)
+	if err != nil {
+		log.Printf("oauth %s: identify failed: %v", provider, err)
+		return nil, nil, ErrOAuthFailed
+	}
+
+	now := time.Now()
+	u, err := s.resolveUser(provider, id, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
+	}
+	if u.PasswordResetRequired {
+		return nil, nil, ErrPasswordResetReq
+	}
+	// 第三方登录不能绕过本站的二次验证
+	if u.MFAEnabled() {
+		return nil, nil, s.Auth.newMFAChallenge(u, now)
+	}
+
+	_ = s.Users.UpdateLastLoginAt(u.ID, now)
+	tokens, err := s.Auth.startSession(u, false, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, u, nil
+}
+
+// 已绑定的第三方身份
+func (s *OAuthService) ListIdentities(userID uint) ([]models.UserIdentity, error) {
+	return s.Identities.ListByUser(userID)
+}
+
+/*
+* 解绑第三方身份；没有密码且只剩这一个身份时不允许（否则无法再登录）
+ */
+func (s *OAuthService) Unlink(userID, identityID uint) error {
+	u, err := s.Auth.findUser(userID)
+	if err != nil {
+		return err
+	}
+	items, err := s.Identities.ListByUser(userID)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, it := range items {
+		if it.ID == identityID {
+			found = true
+		}
+	}
+	if !found {
+		return ErrIdentityNotFound
+	}
+	if u.PasswordHash == "" && len(items) <= 1 {
+		return ErrLastLoginMethod
+	}
+	if err := s.Identities.Delete(userID, identityID); err != nil {
+		if repositories.IsNotFound(err) {
+			return ErrIdentityNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// 按 provider+subject 找已绑定用户；没有则按邮箱绑定或新建
+func (s *OAuthService) resolveUser(provider string, id *oauth.Identity, now time.Time) (*models.User, error) {
+	ident, err := s.Identities.FindByProviderSubject(provider, id.Subject)
+	if err == nil {
+		_ = s.Identities.TouchLogin(ident.ID, now)
+		return s.Users.FindByID(ident.UserID)
+	}
+	if !repositories.IsNotFound(err) {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(strings.ToLower(id.Email))
+	if email == "" {
+		return nil, ErrOAuthEmailRequired
+	}
+	link := &models.UserIdentity{
+		Provider:    provider,
+		Subject:     id.Subject,
+		Email:       email,
+		LastLoginAt: &now,
+	}
+
+	u, err := s.Users.FindByEmail(email)
+	if err == nil {
+		// 双方都验证过邮箱才自动绑定。本地账号未验证时可能是他人抢注的（预先注册攻击），
+		// 绑定后抢注者设置的密码仍然有效，因此要求本站账号先完成邮箱验证
+		if !id.EmailVerified || !u.EmailVerified() {
+			return nil, ErrEmailTaken
+		}
+		link.UserID = u.ID
+		if err := s.Identities.Create(link); err != nil {
+			return nil, err
+		}
+		return u, nil
+	}
+	if !repositories.IsNotFound(err) {
+		return nil, err
+	}
+
+	username, err := s.pickUsername(id, email)
+	if err != nil {
+		return nil, err
+	}
+	// 第三方注册的账号没有密码（密码登录不可用，可通过找回密码设置）
+	u = &models.User{
+		Email:    email,
+		Username: username,
+		Role:     models.RoleUser,
+	}
+	if id.EmailVerified {
+		u.EmailVerifiedAt = &now
+	}
+	if err := s.Identities.CreateWithUser(u, link); err != nil {
+		return nil, err
+	}
+	if !u.EmailVerified() {
+		if err := s.Auth.sendVerification(u, now); err != nil {
+			log.Printf("send verification mail failed: user=%d err=%v", u.ID, err)
+		}
+	}
+	return u, nil
+}
+
+var reUsernameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// 优先用提供方的登录名，其次邮箱前缀；重名时追加随机后缀
+func (s *OAuthService) pickUsername(id *oauth.Identity, email string) (string, error) {
+	base := id.Username
+	if base == "" {
+		base, _, _ = strings.Cut(email, "@")
+	}
+	base = strings.Trim(reUsernameChars.ReplaceAllString(base, "-"), "-")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+
+	name := base
+	for i := 0; i < 5; i++ {
+		taken, err := s.Users.ExistsUsername(name)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return name, nil
+		}
+		name = base + "-" + slug.RandSuffix(3)
+	}
+	return "", errUsernameUnavailable
+}
+
+func (s *OAuthService) encodeState(st oauthState) (string, error) {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + token.Sign(s.StateSecret, "oauth-state\n"+payload), nil
+}
+
+func (s *OAuthService) decodeState(cookie string) (*oauthState, error) {
+	payload, sig, ok := strings.Cut(cookie, ".")
+	if !ok || !token.VerifySignature(s.StateSecret, "oauth-state\n"+payload, sig) {
+		return nil, ErrInvalidOAuthState
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidOAuthState
+	}
+	var st oauthState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return nil, ErrInvalidOAuthState
+	}
+	if st.Expires < time.Now().Unix() {
+		return nil, ErrInvalidOAuthState
+	}
+	return &st, nil
+}
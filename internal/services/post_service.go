@@ -12,23 +12,33 @@ import (
 )
 
 var (
-	ErrPostNotFound    = errors.New("post_not_found")
-	ErrInvalidStatus   = errors.New("invalid_status")
-	ErrTitleRequired   = errors.New("title_required")
-	ErrContentRequired = errors.New("content_required")
+	ErrPostNotFound     = errors.New("post_not_found")
+	ErrInvalidStatus    = errors.New("invalid_status")
+	ErrTitleRequired    = errors.New("title_required")
+	ErrContentRequired  = errors.New("content_required")
+	ErrInvalidPublishAt = errors.New("invalid_publish_at")
+	ErrPostForbidden    = errors.New("forbidden")
 )
 
 type PostService struct {
-	Posts *repositories.PostRepo
-	Tags  *repositories.TagRepo
+	Posts      *repositories.PostRepo
+	Tags       *repositories.TagRepo
+	Categories *repositories.CategoryRepo
+	Revisions  *repositories.RevisionRepo
+	// 已发布文章变化时清空 sitemap 缓存，可为 nil
+	Sitemap *SitemapService
 }
 
 type CreatePostInput struct {
-	Title     string
-	ContentMD string
-	Status    models.PostStatus // draft/published
-	Tags      []string
-	AuthorID  uint
+	Title      string
+	ContentMD  string
+	Status     models.PostStatus // draft/published/scheduled
+	PublishAt  *time.Time        // scheduled 时必填，且必须是将来时间
+	Tags       []string
+	CategoryID *uint
+	AuthorID   uint
+	// 是否有发布权限（posts:publish）；没有时只能保存草稿
+	CanPublish bool
 }
 
 func (s *PostService) Create(in CreatePostInput) (*models.Post, error) {
@@ -39,9 +49,12 @@ func (s *PostService) Create(in CreatePostInput) (*models.Post, error) {
 	if strings.TrimSpace(in.ContentMD) == "" {
 		return nil, ErrContentRequired
 	}
-	if in.Status != models.PostDraft && in.Status != models.PostPublished {
+	if !validPostStatus(in.Status) {
 		return nil, ErrInvalidStatus
 	}
+	if in.Status != models.PostDraft && !in.CanPublish {
+		return nil, ErrPostForbidden
+	}
 
 	html, err := markdown.RenderToSafeHTML(in.ContentMD)
 	if err != nil {
@@ -59,9 +72,16 @@ func (s *PostService) Create(in CreatePostInput) (*models.Post, error) {
 	}
 
 	var publishedAt *time.Time
-	if in.Status == models.PostPublished {
+	switch in.Status {
+	case models.PostPublished:
 		now := time.Now()
 		publishedAt = &now
+	case models.PostScheduled:
+		if in.PublishAt == nil || !in.PublishAt.After(time.Now()) {
+			return nil, ErrInvalidPublishAt
+		}
+		at := *in.PublishAt
+		publishedAt = &at
 	}
 
 	p := &models.Post{
@@ -74,6 +94,15 @@ func (s *PostService) Create(in CreatePostInput) (*models.Post, error) {
 		AuthorID:    in.AuthorID,
 	}
 
+	if in.CategoryID != nil && *in.CategoryID != 0 {
+		c, err := s.findCategory(*in.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		p.CategoryID = &c.ID
+		p.Category = c
+	}
+
 	if len(in.Tags) > 0 {
 		tags, err := s.Tags.GetOrCreateByNames(in.Tags)
 		if err != nil {
@@ -82,9 +111,10 @@ func (s *PostService) Create(in CreatePostInput) (*models.Post, error) {
 		p.Tags = tags
 	}
 
-	if err := s.Posts.Create(p); err != nil {
+	if err := s.Posts.CreateWithRevision(p, newRevision(p, in.AuthorID)); err != nil {
 		return nil, err
 	}
+	s.publicChanged(p.Status)
 	return p, nil
 }
 
@@ -92,17 +122,26 @@ type UpdatePostInput struct {
 	Title     *string
 	ContentMD *string
 	Status    *models.PostStatus
+	PublishAt *time.Time // 定时发布时间：status=scheduled 或已处于 scheduled 时生效
 	Tags      *[]string
+	// 传 0 表示取消分类
+	CategoryID *uint
+	// 本次修改的操作者，记入修订历史
+	EditorID uint
+	// posts:edit_others：可修改他人的文章
+	EditOthers bool
+	// posts:publish：可把文章改为 published/scheduled
+	CanPublish bool
 }
 
 func (s *PostService) Update(postID uint, in UpdatePostInput) (*models.Post, error) {
-	p, err := s.Posts.FindByID(postID)
+	p, err := s.findOwnPost(postID, in.EditorID, in.EditOthers)
 	if err != nil {
-		if repositories.IsNotFound(err) {
-			return nil, ErrPostNotFound
-		}
 		return nil, err
 	}
+	// 修改前的快照：历史数据没有任何修订时补写，保证第一次修改也能回滚
+	before := newRevision(p, p.AuthorID)
+	prevStatus := p.Status
 
 	if in.Title != nil {
 		t := strings.TrimSpace(*in.Title)
@@ -126,16 +165,42 @@ func (s *PostService) Update(postID uint, in UpdatePostInput) (*models.Post, err
 		p.ContentHTML = html
 	}
 
-	if in.Status != nil {
-		if *in.Status != models.PostDraft && *in.Status != models.PostPublished {
+	if in.Status != nil || in.PublishAt != nil {
+		status := p.Status
+		if in.Status != nil {
+			status = *in.Status
+		}
+		if !validPostStatus(status) {
 			return nil, ErrInvalidStatus
 		}
-		// draft -> published 时补发布时间
-		if p.Status != models.PostPublished && *in.Status == models.PostPublished {
-			now := time.Now()
-			p.PublishedAt = &now
+		// 无发布权限时不能发布/定时，但可以编辑已发布的文章或撤回为草稿
+		if status != models.PostDraft && (status != p.Status || in.PublishAt != nil) && !in.CanPublish {
+			return nil, ErrPostForbidden
+		}
+		switch status {
+		case models.PostPublished:
+			// draft/scheduled -> published 时补发布时间
+			if p.Status != models.PostPublished {
+				now := time.Now()
+				p.PublishedAt = &now
+			}
+		case models.PostScheduled:
+			at := in.PublishAt
+			if at == nil && p.Status == models.PostScheduled {
+				at = p.PublishedAt
+			}
+			if at == nil || !at.After(time.Now()) {
+				return nil, ErrInvalidPublishAt
+			}
+			t := *at
+			p.PublishedAt = &t
+		case models.PostDraft:
+			// 取消定时：清掉尚未到来的发布时间
+			if p.Status == models.PostScheduled {
+				p.PublishedAt = nil
+			}
 		}
-		p.Status = *in.Status
+		p.Status = status
 	}
 
 	if in.Tags != nil {
@@ -146,12 +211,110 @@ func (s *PostService) Update(postID uint, in UpdatePostInput) (*models.Post, err
 		p.Tags = tags
 	}
 
-	if err := s.Posts.Update(p); err != nil {
+	if in.CategoryID != nil {
+		if *in.CategoryID == 0 {
+			p.CategoryID = nil
+			p.Category = nil
+		} else {
+			c, err := s.findCategory(*in.CategoryID)
+			if err != nil {
+				return nil, err
+			}
+			p.CategoryID = &c.ID
+			p.Category = c
+		}
+	}
+
+	revs := []*models.PostRevision{newRevision(p, in.EditorID)}
+	n, err := s.Revisions.CountByPost(p.ID)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		revs = append([]*models.PostRevision{before}, revs...)
+	}
+	if err := s.Posts.UpdateWithRevisions(p, revs...); err != nil {
+		return nil, err
+	}
+	s.publicChanged(prevStatus, p.Status)
+	return p, nil
+}
+
+func (s *PostService) Delete(postID, userID uint, editOthers bool) error {
+	p, err := s.findOwnPost(postID, userID, editOthers)
+	if err != nil {
+		return err
+	}
+	if err := s.Posts.DeleteByID(postID); err != nil {
+		return err
+	}
+	s.publicChanged(p.Status)
+	return nil
+}
+
+/*
+* 发布到期的定时文章（后台任务调用），发布后的处理与手动发布相同
+* @return 本次发布的文章 id
+ */
+func (s *PostService) PublishDue(now time.Time, limit int) ([]uint, error) {
+	ids, err := s.Posts.PublishDue(now, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		s.publicChanged(models.PostPublished)
+	}
+	return ids, nil
+}
+
+// 涉及已发布文章（发布、修改、撤回、删除）时清空 sitemap 缓存；草稿的变化不影响
+func (s *PostService) publicChanged(statuses ...models.PostStatus) {
+	if s.Sitemap == nil {
+		return
+	}
+	for _, st := range statuses {
+		if st == models.PostPublished {
+			s.Sitemap.Invalidate()
+			return
+		}
+	}
+}
+
+// 查找文章并校验归属：非作者需要 editOthers 权限
+func (s *PostService) findOwnPost(postID, userID uint, editOthers bool) (*models.Post, error) {
+	p, err := s.findPost(postID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPostOwner(p, userID, editOthers); err != nil {
 		return nil, err
 	}
 	return p, nil
 }
 
+// 作者本人或拥有编辑他人文章权限时可以修改
+func checkPostOwner(p *models.Post, userID uint, editOthers bool) error {
+	if p.AuthorID != userID && !editOthers {
+		return ErrPostForbidden
+	}
+	return nil
+}
+
+func validPostStatus(st models.PostStatus) bool {
+	return st == models.PostDraft || st == models.PostPublished || st == models.PostScheduled
+}
+
+func (s *PostService) findCategory(id uint) (*models.Category, error) {
+	c, err := s.Categories.FindByID(id)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrInvalidCategory
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
 func (s *PostService) Preview(md string) (string, error) {
 	return markdown.RenderToSafeHTML(md)
 }
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"testing"
+
+	"blog-service/internal/models"
+)
+
+// 作者只能修改自己的文章，编辑可以修改任何人的文章
+func TestCheckPostOwner(t *testing.T) {
+	const authorID, otherID = 1, 2
+	p := &models.Post{AuthorID: authorID}
+	cases := []struct {
+		role   models.UserRole
+		userID uint
+		want   error
+	}{
+		{models.RoleAuthor, authorID, nil},
+		{models.RoleAuthor, otherID, ErrPostForbidden},
+		{models.RoleEditor, authorID, nil},
+		{models.RoleEditor, otherID, nil},
+		{models.RoleAdmin, otherID, nil},
+		{models.RoleModerator, otherID, ErrPostForbidden},
+	}
+	for _, c := range cases {
+		editOthers := c.role.Can(models.PermPostsEditOthers)
+		if got := checkPostOwner(p, c.userID, editOthers); got != c.want {
+			t.Errorf("%s user %d: got %v, want %v", c.role, c.userID, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"blog-service/internal/models"
+	"blog-service/internal/repositories"
+	"blog-service/internal/utils/diff"
+)
+
+var ErrRevisionNotFound = errors.New("revision_not_found")
+
+// diff 上下文行数
+const revisionDiffContext = 3
+
+type RevisionDiff struct {
+	From    *models.PostRevision
+	To      *models.PostRevision
+	Unified string // 为空表示两个版本内容相同
+}
+
+func newRevision(p *models.Post, editorID uint) *models.PostRevision {
+	names := make([]string, 0, len(p.Tags))
+	for _, t := range p.Tags {
+		names = append(names, t.Name)
+	}
+	return &models.PostRevision{
+		PostID:    p.ID,
+		Title:     p.Title,
+		ContentMD: p.ContentMD,
+		Tags:      names,
+		EditorID:  editorID,
+	}
+}
+
+func revisionTags(rev *models.PostRevision) []string {
+	if rev.Tags == nil {
+		return []string{}
+	}
+	return rev.Tags
+}
+
+// 参与 diff 的文本：标题、标签和正文
+func revisionText(rev *models.PostRevision) string {
+	return fmt.Sprintf("title: %s\ntags: %s\n\n%s", rev.Title, strings.Join(revisionTags(rev), ", "), rev.ContentMD)
+}
+
+// 修订列表（不含正文），最新在前
+func (s *PostService) ListRevisions(postID, userID uint, editOthers bool) ([]models.PostRevision, error) {
+	if _, err := s.findOwnPost(postID, userID, editOthers); err != nil {
+		return nil, err
+	}
+	return s.Revisions.ListByPost(postID)
+}
+
+func (s *PostService) GetRevision(postID, revID, userID uint, editOthers bool) (*models.PostRevision, error) {
+	if _, err := s.findOwnPost(postID, userID, editOthers); err != nil {
+		return nil, err
+	}
+	return s.findRevision(postID, revID)
+}
+
+func (s *PostService) findRevision(postID, revID uint) (*models.PostRevision, error) {
+	rev, err := s.Revisions.FindByID(postID, revID)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	return rev, nil
+}
+
+func (s *PostService) DiffRevisions(postID, fromID, toID, userID uint, editOthers bool) (*RevisionDiff, error) {
+	if _, err := s.findOwnPost(postID, userID, editOthers); err != nil {
+		return nil, err
+	}
+	from, err := s.findRevision(postID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.findRevision(postID, toID)
+	if err != nil {
+		return nil, err
+	}
+	return &RevisionDiff{
+		From: from,
+		To:   to,
+		Unified: diff.Unified(
+			revisionText(from), revisionText(to),
+			fmt.Sprintf("revision/%d", from.ID), fmt.Sprintf("revision/%d", to.ID),
+			revisionDiffContext,
+		),
+	}, nil
+}
+
+// 回滚到指定修订：标题、正文和标签恢复为该版本，并产生一条新的修订（不改变发布状态）
+func (s *PostService) RestoreRevision(postID, revID, editorID uint, editOthers bool) (*models.Post, error) {
+	rev, err := s.GetRevision(postID, revID, editorID, editOthers)
+	if err != nil {
+		return nil, err
+	}
+	tags := revisionTags(rev)
+	return s.Update(postID, UpdatePostInput{
+		Title:      &rev.Title,
+		ContentMD:  &rev.ContentMD,
+		Tags:       &tags,
+		EditorID:   editorID,
+		EditOthers: editOthers,
+	})
+}
+
+func (s *PostService) findPost(id uint) (*models.Post, error) {
+	p, err := s.Posts.FindByID(id)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
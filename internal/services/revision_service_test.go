@@ -0,0 +1,48 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"blog-service/internal/models"
+)
+
+// 标签名含逗号时，经数据库往返后回滚仍得到原来的标签
+func TestRevisionTagsWithComma(t *testing.T) {
+	p := &models.Post{
+		Title:     "t",
+		ContentMD: "body",
+		Tags:      []models.Tag{{Name: "c,c++"}, {Name: "go"}},
+	}
+	rev := newRevision(p, 1)
+
+	v, err := rev.Tags.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded models.PostRevision
+	if err := loaded.Tags.Scan([]byte(v.(string))); err != nil {
+		t.Fatal(err)
+	}
+	if got := revisionTags(&loaded); !reflect.DeepEqual(got, []string{"c,c++", "go"}) {
+		t.Fatalf("restored tags %q", got)
+	}
+}
+
+func TestRevisionTagsEmpty(t *testing.T) {
+	rev := newRevision(&models.Post{}, 1)
+	v, _ := rev.Tags.Value()
+	if v != "[]" {
+		t.Fatalf("want [] got %v", v)
+	}
+	// 数据库中为 NULL 或空串时视为没有标签
+	var loaded models.PostRevision
+	for _, raw := range []any{nil, []byte{}} {
+		if err := loaded.Tags.Scan(raw); err != nil {
+			t.Fatal(err)
+		}
+		if got := revisionTags(&loaded); got == nil || len(got) != 0 {
+			t.Fatalf("want empty tags got %#v", got)
+		}
+	}
+}
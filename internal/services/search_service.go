@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"blog-service/internal/repositories"
+	"blog-service/internal/utils/ftquery"
+	"blog-service/internal/utils/markdown"
+	"blog-service/internal/utils/snippet"
+)
+
+var ErrQueryRequired = errors.New("query_required")
+
+const searchSnippetWidth = 160
+
+type SearchService struct {
+	Posts *repositories.PostRepo
+}
+
+type SearchInput struct {
+	Query         string
+	Boolean       bool
+	OnlyPublished bool
+	Page          int
+	Size          int
+}
+
+type SearchResult struct {
+	repositories.PostSearchHit
+	TitleHighlight string
+	Snippet        string
+}
+
+func (s *SearchService) Search(in SearchInput) ([]SearchResult, int64, error) {
+	q := strings.TrimSpace(in.Query)
+	if in.Boolean {
+		// 去掉会让 InnoDB 报语法错误的写法
+		q = ftquery.Boolean(q)
+	}
+	if q == "" {
+		return nil, 0, ErrQueryRequired
+	}
+
+	hits, total, err := s.Posts.Search(q, in.Boolean, in.OnlyPublished, in.Page, in.Size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	terms := searchTerms(q)
+	out := make([]SearchResult, 0, len(hits))
+	for _, h := range hits {
+		text, err := markdown.ToPlainText(h.Post.ContentMD)
+		if err != nil {
+			text = h.Post.ContentMD
+		}
+		out = append(out, SearchResult{
+			PostSearchHit:  h,
+			TitleHighlight: snippet.Highlight(h.Post.Title, terms, 0),
+			Snippet:        snippet.Highlight(text, terms, searchSnippetWidth),
+		})
+	}
+	return out, total, nil
+}
+
+// 从查询串中提取高亮用的关键词：去掉 BOOLEAN MODE 的运算符
+func searchTerms(q string) []string {
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case '+', '-', '<', '>', '(', ')', '~', '*', '"', '@':
+			return ' '
+		}
+		return r
+	}, q)
+	return strings.Fields(cleaned)
+}
@@ -0,0 +1,13 @@
+package services
+
+import "testing"
+
+// 整理后没有可检索的词时直接返回 ErrQueryRequired，不会把非法表达式交给数据库
+func TestSearchMalformedBooleanQuery(t *testing.T) {
+	s := &SearchService{}
+	for _, q := range []string{`"`, "+", "()", "@@ -"} {
+		if _, _, err := s.Search(SearchInput{Query: q, Boolean: true}); err != ErrQueryRequired {
+			t.Errorf("Search(%q) err = %v, want ErrQueryRequired", q, err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"blog-service/internal/repositories"
+	"blog-service/internal/sitemap"
+)
+
+var ErrSitemapNotFound = errors.New("sitemap_not_found")
+
+// 缓存默认有效期（多实例部署时其它实例上的变化只能靠过期生效）
+const defaultSitemapTTL = time.Hour
+
+// sitemap 索引 + 分页 sitemap，生成结果缓存在内存中，文章变化时清空
+type SitemapService struct {
+	Posts      *repositories.PostRepo
+	Tags       *repositories.TagRepo
+	Categories *repositories.CategoryRepo
+
+	BaseURL string
+	TTL     time.Duration
+
+	mu    sync.Mutex
+	cache map[string]sitemapEntry
+}
+
+type sitemapEntry struct {
+	body  []byte
+	built time.Time
+}
+
+// 清空缓存，下次请求时重新生成；s 为 nil 时什么也不做
+func (s *SitemapService) Invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.cache = nil
+	s.mu.Unlock()
+}
+
+/*
+* 取 sitemap 内容
+* @param name index（/sitemap.xml）、pages.xml（首页/标签/分类）或 posts-{n}.xml
+* @return modTime 生成时间，用作 Last-Modified
+ */
+func (s *SitemapService) Get(name string) (body []byte, modTime time.Time, err error) {
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = defaultSitemapTTL
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.cache[name]; ok && time.Since(e.built) < ttl {
+		return e.body, e.built, nil
+	}
+
+	body, err = s.build(name)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	// Last-Modified 精度为秒
+	now := time.Now().Truncate(time.Second)
+	if s.cache == nil {
+		s.cache = make(map[string]sitemapEntry)
+	}
+	s.cache[name] = sitemapEntry{body: body, built: now}
+	return body, now, nil
+}
+
+func (s *SitemapService) build(name string) ([]byte, error) {
+	switch name {
+	case "index":
+		return s.buildIndex()
+	case "pages.xml":
+		return s.buildPages()
+	}
+	num, ok := strings.CutPrefix(name, "posts-")
+	if !ok {
+		return nil, ErrSitemapNotFound
+	}
+	num, ok = strings.CutSuffix(num, ".xml")
+	n, err := strconv.Atoi(num)
+	// 只接受规范写法（posts-01.xml 之类不缓存第二份）
+	if !ok || err != nil || n < 1 || strconv.Itoa(n) != num {
+		return nil, ErrSitemapNotFound
+	}
+	return s.buildPosts(n)
+}
+
+func (s *SitemapService) base() string {
+	return strings.TrimRight(s.BaseURL, "/")
+}
+
+func (s *SitemapService) postPages() (int, error) {
+	total, err := s.Posts.CountPublished()
+	if err != nil {
+		return 0, err
+	}
+	return int((total + sitemap.MaxURLs - 1) / sitemap.MaxURLs), nil
+}
+
+func (s *SitemapService) buildIndex() ([]byte, error) {
+	base := s.base()
+	pages, err := s.postPages()
+	if err != nil {
+		return nil, err
+	}
+
+	items := []sitemap.URL{{Loc: base + "/sitemaps/pages.xml"}}
+	for n := 1; n <= pages; n++ {
+		lm, err := s.Posts.SitemapLastMod((n-1)*sitemap.MaxURLs, sitemap.MaxURLs)
+		if err != nil {
+			return nil, err
+		}
+		u := sitemap.URL{Loc: base + "/sitemaps/posts-" + strconv.Itoa(n) + ".xml"}
+		if lm != nil {
+			u.LastMod = *lm
+		}
+		items = append(items, u)
+	}
+	return sitemap.Index(items)
+}
+
+// 首页、标签页、分类页
+func (s *SitemapService) buildPages() ([]byte, error) {
+	base := s.base()
+	tags, err := s.Tags.ListSitemap()
+	if err != nil {
+		return nil, err
+	}
+	cats, err := s.Categories.ListSitemap()
+	if err != nil {
+		return nil, err
+	}
+
+	// 首页随最近一篇文章变化（limit -1 表示不分页）
+	latest, err := s.Posts.SitemapLastMod(0, -1)
+	if err != nil {
+		return nil, err
+	}
+	home := sitemap.URL{Loc: base + "/"}
+	if latest != nil {
+		home.LastMod = *latest
+	}
+
+	urls := []sitemap.URL{home}
+	for _, t := range tags {
+		urls = append(urls, sitemap.URL{Loc: base + "/tags/" + url.PathEscape(t.Name), LastMod: t.LastMod})
+	}
+	for _, c := range cats {
+		urls = append(urls, sitemap.URL{Loc: base + "/categories/" + url.PathEscape(c.Slug), LastMod: c.LastMod})
+	}
+	if len(urls) > sitemap.MaxURLs {
+		urls = urls[:sitemap.MaxURLs]
+	}
+	return sitemap.URLSet(urls)
+}
+
+func (s *SitemapService) buildPosts(n int) ([]byte, error) {
+	pages, err := s.postPages()
+	if err != nil {
+		return nil, err
+	}
+	if n > pages {
+		return nil, ErrSitemapNotFound
+	}
+	posts, err := s.Posts.ListSitemap((n-1)*sitemap.MaxURLs, sitemap.MaxURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	base := s.base()
+	urls := make([]sitemap.URL, 0, len(posts))
+	for _, p := range posts {
+		u := sitemap.URL{Loc: base + "/posts/" + url.PathEscape(p.Slug), LastMod: p.UpdatedAt}
+		if p.PublishedAt != nil && p.PublishedAt.After(u.LastMod) {
+			u.LastMod = *p.PublishedAt
+		}
+		urls = append(urls, u)
+	}
+	return sitemap.URLSet(urls)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"blog-service/internal/models"
+	"blog-service/internal/repositories"
+)
+
+var (
+	ErrTagNotFound     = errors.New("tag_not_found")
+	ErrTagExists       = errors.New("tag_exists")
+	ErrTagNameRequired = errors.New("tag_name_required")
+	ErrSameTag         = errors.New("same_tag")
+)
+
+type TagService struct {
+	Tags  *repositories.TagRepo
+	Posts *repositories.PostRepo
+	// 标签改名、合并、删除时清空 sitemap 缓存，可为 nil
+	Sitemap *SitemapService
+}
+
+func (s *TagService) List(sort string) ([]repositories.TagWithCount, error) {
+	return s.Tags.ListWithCounts(sort)
+}
+
+func (s *TagService) ListPosts(name string, page, size int) ([]models.Post, int64, error) {
+	t, err := s.Tags.FindByName(name)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, 0, ErrTagNotFound
+		}
+		return nil, 0, err
+	}
+	return s.Posts.ListPublishedByTag(t.Name, page, size)
+}
+
+// 重命名；目标名已存在时返回 ErrTagExists（应改用合并）
+func (s *TagService) Rename(id uint, name string) (*models.Tag, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil, ErrTagNameRequired
+	}
+	t, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Name == name {
+		return t, nil
+	}
+	if other, err := s.Tags.FindByName(name); err == nil && other.ID != t.ID {
+		return nil, ErrTagExists
+	} else if err != nil && !repositories.IsNotFound(err) {
+		return nil, err
+	}
+
+	if err := s.Tags.Rename(t.ID, name); err != nil {
+		return nil, err
+	}
+	s.Sitemap.Invalidate()
+	t.Name = name
+	return t, nil
+}
+
+// 把 fromID 合并进 intoID，返回合并后的目标标签
+func (s *TagService) Merge(fromID, intoID uint) (*models.Tag, error) {
+	if fromID == intoID {
+		return nil, ErrSameTag
+	}
+	if _, err := s.findByID(fromID); err != nil {
+		return nil, err
+	}
+	into, err := s.findByID(intoID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Tags.Merge(fromID, intoID); err != nil {
+		return nil, err
+	}
+	s.Sitemap.Invalidate()
+	return into, nil
+}
+
+func (s *TagService) DeleteUnused() (int64, error) {
+	n, err := s.Tags.DeleteUnused()
+	if err == nil && n > 0 {
+		s.Sitemap.Invalidate()
+	}
+	return n, err
+}
+
+func (s *TagService) findByID(id uint) (*models.Tag, error) {
+	t, err := s.Tags.FindByID(id)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"blog-service/internal/models"
+	"blog-service/internal/repositories"
+)
+
+var (
+	ErrUserNotFound     = errors.New("user_not_found")
+	ErrInvalidRole      = errors.New("invalid_role")
+	ErrCannotModifySelf = errors.New("cannot_modify_self")
+	ErrLastAdmin        = errors.New("last_admin")
+	ErrInvalidReassign  = errors.New("invalid_reassign_target")
+)
+
+// 管理后台的用户管理
+type UserService struct {
+	Users   *repositories.UserRepo
+	Refresh *repositories.RefreshTokenRepo
+	Auth    *AuthService // 强制重置密码时复用重置邮件流程
+}
+
+func (s *UserService) List(f repositories.UserFilter, page, size int) ([]models.User, int64, error) {
+	return s.Users.List(f, page, size)
+}
+
+func (s *UserService) Get(id uint) (*models.User, error) {
+	return s.find(id)
+}
+
+/*
+* 修改角色：立即生效（鉴权中间件每次请求都从库里读取角色）
+* @param actorID 操作者，不能修改自己
+ */
+func (s *UserService) ChangeRole(actorID, userID uint, role models.UserRole) (*models.User, error) {
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	u, err := s.findOther(actorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Role == role {
+		return u, nil
+	}
+	if err := s.checkNotLastAdmin(u); err != nil {
+		return nil, err
+	}
+	if err := s.Users.UpdateRole(u.ID, role); err != nil {
+		return nil, err
+	}
+	u.Role = role
+	return u, nil
+}
+
+/*
+* 停用/启用账号：停用时同时吊销全部会话
+* @param actorID 操作者，不能停用自己
+ */
+func (s *UserService) SetDisabled(actorID, userID uint, disabled bool) (*models.User, error) {
+	u, err := s.findOther(actorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !disabled {
+		if err := s.Users.SetDisabledAt(u.ID, nil); err != nil {
+			return nil, err
+		}
+		u.DisabledAt = nil
+		return u, nil
+	}
+
+	if u.DisabledAt != nil {
+		return u, nil
+	}
+	if err := s.checkNotLastAdmin(u); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := s.Users.SetDisabledAt(u.ID, &now); err != nil {
+		return nil, err
+	}
+	if err := s.revokeSessions(u.ID, now); err != nil {
+		return nil, err
+	}
+	u.DisabledAt = &now
+	return u, nil
+}
+
+/*
+* 强制重置密码：吊销全部会话，登录被拒绝直到用户通过重置邮件设置新密码
+ */
+func (s *UserService) ForcePasswordReset(userID uint) error {
+	u, err := s.find(userID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := s.Users.SetPasswordResetRequired(u.ID, true); err != nil {
+		return err
+	}
+	if err := s.revokeSessions(u.ID, now); err != nil {
+		return err
+	}
+	return s.Auth.issueResetToken(u, now)
+}
+
+/*
+* 删除用户：其文章、评论等归属转给 reassignTo
+* @param reassignTo 接收者，不能是被删除的用户
+ */
+func (s *UserService) Delete(actorID, userID, reassignTo uint) error {
+	u, err := s.findOther(actorID, userID)
+	if err != nil {
+		return err
+	}
+	if reassignTo == 0 || reassignTo == u.ID {
+		return ErrInvalidReassign
+	}
+	if _, err := s.Users.FindByID(reassignTo); err != nil {
+		if repositories.IsNotFound(err) {
+			return ErrInvalidReassign
+		}
+		return err
+	}
+	if err := s.checkNotLastAdmin(u); err != nil {
+		return err
+	}
+	return s.Users.DeleteAndReassign(u.ID, reassignTo)
+}
+
+func (s *UserService) revokeSessions(userID uint, now time.Time) error {
+	return s.Refresh.RevokeAllForUser(userID, now, now.Add(s.Auth.JWT.TTL))
+}
+
+// 至少保留一个可用的管理员
+func (s *UserService) checkNotLastAdmin(u *models.User) error {
+	if u.Role != models.RoleAdmin || u.DisabledAt != nil {
+		return nil
+	}
+	n, err := s.Users.CountByRole(models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if n <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+func (s *UserService) findOther(actorID, userID uint) (*models.User, error) {
+	if actorID == userID {
+		return nil, ErrCannotModifySelf
+	}
+	return s.find(userID)
+}
+
+func (s *UserService) find(id uint) (*models.User, error) {
+	u, err := s.Users.FindByID(id)
+	if err != nil {
+		if repositories.IsNotFound(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return u, nil
+}
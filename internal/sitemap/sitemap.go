@@ -0,0 +1,88 @@
+package sitemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// 单个 sitemap 文件最多 50000 条 URL（sitemaps.org 协议上限）
+const MaxURLs = 50000
+
+const ContentType = "application/xml; charset=utf-8"
+
+const xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// 一条 URL（或 sitemap 索引中的一个子 sitemap）；LastMod 为零时省略
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type urlSet struct {
+	XMLName xml.Name   `xml:"urlset"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	URLs    []urlEntry `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name   `xml:"sitemapindex"`
+	Xmlns    string     `xml:"xmlns,attr"`
+	Sitemaps []urlEntry `xml:"sitemap"`
+}
+
+type urlEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+func entries(urls []URL) []urlEntry {
+	out := make([]urlEntry, 0, len(urls))
+	for _, u := range urls {
+		e := urlEntry{Loc: u.Loc}
+		if !u.LastMod.IsZero() {
+			e.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// <urlset>；超过 MaxURLs 的部分由调用方分页
+func URLSet(urls []URL) ([]byte, error) {
+	return marshal(urlSet{Xmlns: xmlns, URLs: entries(urls)})
+}
+
+// <sitemapindex>
+func Index(sitemaps []URL) ([]byte, error) {
+	return marshal(sitemapIndex{Xmlns: xmlns, Sitemaps: entries(sitemaps)})
+}
+
+func marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// 生成 robots.txt：对所有爬虫禁止 disallow 中的路径，并声明 sitemap 地址
+func Robots(disallow []string, sitemapURL string) []byte {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if len(disallow) == 0 {
+		b.WriteString("Disallow:\n")
+	}
+	for _, p := range disallow {
+		b.WriteString("Disallow: " + p + "\n")
+	}
+	if sitemapURL != "" {
+		b.WriteString("\nSitemap: " + sitemapURL + "\n")
+	}
+	return []byte(b.String())
+}
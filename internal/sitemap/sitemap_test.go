@@ -0,0 +1,60 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSet(t *testing.T) {
+	b, err := URLSet([]URL{
+		{Loc: "https://blog.example.com/", LastMod: time.Date(2024, 5, 1, 8, 0, 0, 0, time.FixedZone("x", 8*3600))},
+		{Loc: "https://blog.example.com/tags/c&go"},
+	})
+	if err != nil {
+		t.Fatalf("urlset err: %v", err)
+	}
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+		URLs    []struct {
+			Loc     string `xml:"loc"`
+			LastMod string `xml:"lastmod"`
+		} `xml:"url"`
+	}
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("invalid xml: %v\n%s", err, b)
+	}
+	if len(doc.URLs) != 2 {
+		t.Fatalf("want 2 urls, got %d", len(doc.URLs))
+	}
+	if doc.URLs[0].LastMod != "2024-05-01T00:00:00Z" {
+		t.Fatalf("lastmod %s", doc.URLs[0].LastMod)
+	}
+	if doc.URLs[1].Loc != "https://blog.example.com/tags/c&go" || strings.Contains(string(b), "<lastmod></lastmod>") {
+		t.Fatalf("unexpected output:\n%s", b)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	b, err := Index([]URL{{Loc: "https://blog.example.com/sitemaps/posts-1.xml"}})
+	if err != nil {
+		t.Fatalf("index err: %v", err)
+	}
+	if !strings.Contains(string(b), `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`) ||
+		!strings.Contains(string(b), "<loc>https://blog.example.com/sitemaps/posts-1.xml</loc>") {
+		t.Fatalf("unexpected output:\n%s", b)
+	}
+}
+
+func TestRobots(t *testing.T) {
+	got := string(Robots([]string{"/api/", "/uploads/private/"}, "https://blog.example.com/sitemap.xml"))
+	want := "User-agent: *\nDisallow: /api/\nDisallow: /uploads/private/\n\nSitemap: https://blog.example.com/sitemap.xml\n"
+	if got != want {
+		t.Fatalf("got %q", got)
+	}
+	// 空的 Disallow 表示全部允许
+	if got := string(Robots(nil, "")); got != "User-agent: *\nDisallow:\n" {
+		t.Fatalf("got %q", got)
+	}
+}
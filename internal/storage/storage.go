@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("object_not_found")
+
+// 上传文件的存储后端抽象；key 为内容寻址的相对路径（如 ab/cd/<sha256>.png）
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// 对外公开访问地址
+	URL(key string) string
+}
@@ -0,0 +1,210 @@
+package theme
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"blog-service/internal/models"
+)
+
+//go:embed templates/*.html
+var defaultTemplates embed.FS
+
+// 每个页面模板与公共模板（layout.html、partials.html）组合成独立的模板集，
+// 页面模板定义 "content"，由 layout.html 中的 "layout" 引用
+const (
+	PageHome     = "home.html"
+	PagePost     = "post.html"
+	PageTag      = "tag.html"
+	PageCategory = "category.html"
+	PageNotFound = "not_found.html"
+)
+
+var (
+	pages  = []string{PageHome, PagePost, PageTag, PageCategory, PageNotFound}
+	shared = []string{"layout.html", "partials.html"}
+)
+
+// 服务端渲染的主题：默认模板内嵌在二进制中，覆盖目录中的同名文件优先
+type Theme struct {
+	pages map[string]*template.Template
+}
+
+/*
+* 加载主题
+* @param overrideDir 覆盖目录，可为空；只需放要替换的文件（如只改 layout.html）
+ */
+func New(overrideDir string) (*Theme, error) {
+	if overrideDir != "" {
+		if st, err := os.Stat(overrideDir); err != nil || !st.IsDir() {
+			return nil, fmt.Errorf("theme dir %q is not a directory", overrideDir)
+		}
+	}
+	read := func(name string) ([]byte, error) {
+		if overrideDir != "" {
+			b, err := os.ReadFile(filepath.Join(overrideDir, name))
+			if err == nil {
+				return b, nil
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+		}
+		return defaultTemplates.ReadFile("templates/" + name)
+	}
+
+	t := &Theme{pages: make(map[string]*template.Template, len(pages))}
+	for _, page := range pages {
+		tpl := template.New(page).Funcs(funcs)
+		for _, name := range append(shared, page) {
+			src, err := read(name)
+			if err != nil {
+				return nil, err
+			}
+			// 页面模板解析到根模板上（New 同名模板会替换掉根模板）
+			target := tpl
+			if name != page {
+				target = tpl.New(name)
+			}
+			if _, err := target.Parse(string(src)); err != nil {
+				return nil, fmt.Errorf("parse %s: %w", name, err)
+			}
+		}
+		if tpl.Lookup("layout") == nil {
+			return nil, fmt.Errorf("%s: template \"layout\" not defined", page)
+		}
+		t.pages[page] = tpl
+	}
+	return t, nil
+}
+
+// 渲染整页；先写入缓冲区，模板执行出错时不会输出半个页面
+func (t *Theme) Render(w io.Writer, page string, data *PageData) error {
+	tpl, ok := t.pages[page]
+	if !ok {
+		return fmt.Errorf("unknown page %q", page)
+	}
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// 站点信息
+type Site struct {
+	Title       string
+	Description string
+	BaseURL     string // 无末尾斜杠
+	Language    string
+}
+
+// 页面 <head> 中的 SEO 信息：canonical、OpenGraph、Twitter card；URL 均为绝对地址
+type Meta struct {
+	Title       string // og:title / twitter:title
+	Description string
+	Canonical   string
+	Type        string // website / article
+	Image       string
+	Author      string
+	Published   *time.Time
+	Modified    *time.Time
+	Tags        []string
+	Prev        string // 分页 rel=prev/next
+	Next        string
+	// 订阅源路径前缀（"" 为全站，/tags/go 为标签），为 nil 时不输出
+	FeedBase *string
+}
+
+type Pager struct {
+	Page       int
+	TotalPages int
+	PrevURL    string // 站内相对地址
+	NextURL    string
+}
+
+// 模板数据；各页面只用到其中一部分
+type PageData struct {
+	Site  Site
+	Title string // <title>
+	Meta  Meta
+
+	Posts    []models.Post
+	Post     *models.Post
+	Tag      string
+	Category *models.Category
+	Pager    *Pager
+}
+
+var funcs = template.FuncMap{
+	// 文章正文在保存时已经过清洗（markdown.RenderToSafeHTML），可以原样输出
+	"trustedHTML": func(s string) template.HTML { return template.HTML(s) },
+	"date":        formatDate,
+	"iso":         formatISO,
+	"pathEscape":  url.PathEscape,
+}
+
+func formatDate(v any) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format("2006-01-02")
+	case *time.Time:
+		if t != nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return ""
+}
+
+func formatISO(v any) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if t != nil {
+			return t.UTC().Format(time.RFC3339)
+		}
+	}
+	return ""
+}
+
+var (
+	reTags     = regexp.MustCompile(`(?s)<[^>]*>`)
+	reSpaces   = regexp.MustCompile(`\s+`)
+	reFirstImg = regexp.MustCompile(`(?i)<img\s[^>]*?src=["']([^"']+)["']`)
+)
+
+// 正文 HTML 转纯文本摘要（meta description），最多 n 个字符
+func Excerpt(s string, n int) string {
+	s = reTags.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	s = strings.TrimSpace(reSpaces.ReplaceAllString(s, " "))
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return strings.TrimSpace(string(r[:n])) + "…"
+}
+
+// 正文中的第一张图片（og:image），没有时返回空串
+func FirstImage(s string) string {
+	m := reFirstImg.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	return html.UnescapeString(m[1])
+}
@@ -0,0 +1,153 @@
+package theme
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"blog-service/internal/models"
+)
+
+func samplePost() *models.Post {
+	pub := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	return &models.Post{
+		ID:          1,
+		Title:       "Hello <World>",
+		Slug:        "hello-world",
+		ContentHTML: `<p>正文 <strong>内容</strong></p><img src="/uploads/a.png">`,
+		PublishedAt: &pub,
+		Author:      models.User{Username: "alice"},
+		Tags:        []models.Tag{{Name: "go"}, {Name: "c#"}},
+	}
+}
+
+func TestRenderPost(t *testing.T) {
+	th, err := New("")
+	if err != nil {
+		t.Fatalf("load theme: %v", err)
+	}
+	p := samplePost()
+	var buf bytes.Buffer
+	err = th.Render(&buf, PagePost, &PageData{
+		Site:  Site{Title: "Blog", BaseURL: "https://blog.example.com", Language: "zh-CN"},
+		Title: p.Title + " - Blog",
+		Meta: Meta{
+			Title:     p.Title,
+			Canonical: "https://blog.example.com/posts/hello-world",
+			Type:      "article",
+			Image:     "https://blog.example.com/uploads/a.png",
+			Published: p.PublishedAt,
+			Tags:      []string{"go"},
+		},
+		Post: p,
+	})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<link rel="canonical" href="https://blog.example.com/posts/hello-world">`,
+		`<meta property="og:title" content="Hello &lt;World&gt;">`,
+		`<meta property="og:type" content="article">`,
+		`<meta property="article:published_time" content="2024-05-01T08:00:00Z">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+		`<h1>Hello &lt;World&gt;</h1>`,
+		// 正文原样输出
+		`<p>正文 <strong>内容</strong></p>`,
+		`<a href="/tags/c%23">#c#</a>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+}
+
+func TestRenderListWithPager(t *testing.T) {
+	th, err := New("")
+	if err != nil {
+		t.Fatalf("load theme: %v", err)
+	}
+	var buf bytes.Buffer
+	feeds := "/tags/go"
+	err = th.Render(&buf, PageTag, &PageData{
+		Site:  Site{Title: "Blog"},
+		Title: "#go - Blog",
+		Meta: Meta{
+			Title:     "#go",
+			Canonical: "https://blog.example.com/tags/go?page=2",
+			Type:      "website",
+			Prev:      "https://blog.example.com/tags/go",
+			FeedBase:  &feeds,
+		},
+		Posts: []models.Post{*samplePost()},
+		Tag:   "go",
+		Pager: &Pager{Page: 2, TotalPages: 2, PrevURL: "/tags/go"},
+	})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<link rel="prev" href="https://blog.example.com/tags/go">`,
+		`<link rel="alternate" type="application/atom+xml" title="Atom" href="/tags/go/atom.xml">`,
+		`<a href="/posts/hello-world">Hello &lt;World&gt;</a>`,
+		`<a href="/tags/go" rel="prev">`,
+		`2 / 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	if strings.Contains(out, `rel="next"`) {
+		t.Errorf("last page should not link to next")
+	}
+}
+
+func TestOverrideDir(t *testing.T) {
+	dir := t.TempDir()
+	layout := `{{define "layout"}}custom:{{template "content" .}}{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "layout.html"), []byte(layout), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	th, err := New(dir)
+	if err != nil {
+		t.Fatalf("load theme: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := th.Render(&buf, PageNotFound, &PageData{}); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	// 覆盖了 layout，页面模板仍用内置的
+	if !strings.HasPrefix(buf.String(), "custom:<h1>页面不存在</h1>") {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "post.html"), []byte(`{{define "content"}}{{.Missing}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(dir); err == nil {
+		t.Fatalf("broken override should fail to load")
+	}
+	if _, err := New(filepath.Join(dir, "nope")); err == nil {
+		t.Fatalf("missing dir should fail to load")
+	}
+}
+
+func TestExcerptAndFirstImage(t *testing.T) {
+	s := "<h1>标题</h1>\n<p>a &amp; b   <code>x</code></p>"
+	if got := Excerpt(s, 100); got != "标题 a & b x" {
+		t.Fatalf("excerpt %q", got)
+	}
+	if got := Excerpt(s, 2); got != "标题…" {
+		t.Fatalf("excerpt %q", got)
+	}
+	if got := FirstImage(`<p><img alt="x" src="/uploads/a.png?w=1&amp;h=2"></p>`); got != "/uploads/a.png?w=1&h=2" {
+		t.Fatalf("image %q", got)
+	}
+	if got := FirstImage("<p>none</p>"); got != "" {
+		t.Fatalf("image %q", got)
+	}
+}
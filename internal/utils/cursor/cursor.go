@@ -0,0 +1,60 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrInvalid = errors.New("invalid_cursor")
+
+// 键集分页游标：排序键 (Time, ID) 或 (N, ID)，对客户端不透明
+type Cursor struct {
+	Key  string // 排序键名（如 published / views），防止游标串用到别的列表
+	Time time.Time
+	N    uint64 // 按计数（浏览量等）排序时使用
+	ID   uint
+	// 向前翻页（上一页）；默认向后（下一页）
+	Prev bool
+}
+
+type payload struct {
+	K string `json:"k"`
+	T int64  `json:"t,omitempty"` // UnixMicro，与 DATETIME(3) 精度兼容
+	N uint64 `json:"n,omitempty"`
+	I uint   `json:"i"`
+	P bool   `json:"p,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	p := payload{K: c.Key, I: c.ID, N: c.N, P: c.Prev}
+	if !c.Time.IsZero() {
+		p.T = c.Time.UnixMicro()
+	}
+	b, _ := json.Marshal(p)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// 解析游标；key 不一致或格式错误时返回 ErrInvalid
+func Decode(s, key string) (*Cursor, error) {
+	if s == "" || len(s) > 200 {
+		return nil, ErrInvalid
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	var p payload
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, ErrInvalid
+	}
+	if p.K != key || p.I == 0 {
+		return nil, ErrInvalid
+	}
+	c := &Cursor{Key: p.K, N: p.N, ID: p.I, Prev: p.P}
+	if p.T != 0 {
+		c.Time = time.UnixMicro(p.T)
+	}
+	return c, nil
+}
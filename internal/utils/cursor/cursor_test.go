@@ -0,0 +1,49 @@
+package cursor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	at := time.Date(2024, 5, 1, 8, 0, 0, 123456789, time.UTC)
+	c := Cursor{Key: "published", Time: at, ID: 42, Prev: true}
+
+	got, err := Decode(c.Encode(), "published")
+	if err != nil {
+		t.Fatalf("decode err: %v", err)
+	}
+	// 精度截到微秒
+	if !got.Time.Equal(at.Truncate(time.Microsecond)) || got.ID != 42 || !got.Prev || got.Key != "published" {
+		t.Fatalf("unexpected cursor %+v", got)
+	}
+}
+
+func TestRoundTripCount(t *testing.T) {
+	c := Cursor{Key: "views", N: 1024, ID: 7}
+	got, err := Decode(c.Encode(), "views")
+	if err != nil {
+		t.Fatalf("decode err: %v", err)
+	}
+	if got.N != 1024 || got.ID != 7 || got.Prev || !got.Time.IsZero() {
+		t.Fatalf("unexpected cursor %+v", got)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	valid := Cursor{Key: "published", Time: time.Now(), ID: 1}.Encode()
+	for _, s := range []string{
+		"",
+		"not base64!",
+		"bm90IGpzb24",
+		Cursor{Key: "published", Time: time.Now()}.Encode(), // 缺少 id
+	} {
+		if _, err := Decode(s, "published"); err != ErrInvalid {
+			t.Fatalf("%q: want ErrInvalid, got %v", s, err)
+		}
+	}
+	// 其它列表的游标不能混用
+	if _, err := Decode(valid, "created"); err != ErrInvalid {
+		t.Fatalf("key mismatch should be invalid, got %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Kind int
+
+const (
+	Equal Kind = iota
+	Insert
+	Delete
+)
+
+// 一行差异；OldLine/NewLine 为 1 起始的行号，不存在时为 0
+type Line struct {
+	Kind    Kind
+	Text    string
+	OldLine int
+	NewLine int
+}
+
+// 按行比较两段文本（Myers 线性空间算法：分治查找中间蛇，内存 O(N+M)）
+func Lines(a, b string) []Line {
+	x, y := splitLines(a), splitLines(b)
+	d := &differ{a: x, b: y, out: make([]Line, 0, len(x)+len(y))}
+	size := len(x) + len(y) + 2
+	d.vf, d.vb = make([]int, 2*size+1), make([]int, 2*size+1)
+	d.compare(0, len(x), 0, len(y))
+	return d.out
+}
+
+// 生成 unified diff 文本；context 为每个变更块前后保留的上下文行数
+func Unified(a, b, fromName, toName string, context int) string {
+	lines := Lines(a, b)
+
+	changed := false
+	for _, l := range lines {
+		if l.Kind != Equal {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	i := 0
+	for i < len(lines) {
+		// 找到下一处变更
+		for i < len(lines) && lines[i].Kind == Equal {
+			i++
+		}
+		if i >= len(lines) {
+			break
+		}
+		start := max(i-context, 0)
+
+		// 向后扩展：相邻变更之间的相同行不超过 2*context 时合并为同一块
+		end := i
+		for end < len(lines) {
+			if lines[end].Kind != Equal {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].Kind == Equal {
+				run++
+			}
+			if run >= len(lines) || run-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+			end = run
+		}
+
+		oldBefore, newBefore := 0, 0
+		for _, l := range lines[:start] {
+			if l.Kind != Insert {
+				oldBefore++
+			}
+			if l.Kind != Delete {
+				newBefore++
+			}
+		}
+		writeHunk(&sb, lines[start:end], oldBefore, newBefore)
+		i = end
+	}
+	return sb.String()
+}
+
+// oldBefore/newBefore 为块之前旧/新文本已经过的行数
+func writeHunk(sb *strings.Builder, hunk []Line, oldBefore, newBefore int) {
+	oldCount, newCount := 0, 0
+	for _, l := range hunk {
+		if l.Kind != Insert {
+			oldCount++
+		}
+		if l.Kind != Delete {
+			newCount++
+		}
+	}
+	// 按 unified 约定，某侧行数为 0 时起始行取前一行
+	oldStart, newStart := oldBefore, newBefore
+	if oldCount > 0 {
+		oldStart++
+	}
+	if newCount > 0 {
+		newStart++
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, l := range hunk {
+		switch l.Kind {
+		case Equal:
+			sb.WriteString(" ")
+		case Insert:
+			sb.WriteString("+")
+		case Delete:
+			sb.WriteString("-")
+		}
+		sb.WriteString(l.Text)
+		sb.WriteString("\n")
+	}
+}
+
+type differ struct {
+	a, b   []string
+	vf, vb []int // 正向/反向各对角线上走到的最远 x，下标偏移 len(vf)/2
+	out    []Line
+}
+
+// 比较 a[a0:a1] 与 b[b0:b1]，按顺序追加到 out
+func (d *differ) compare(a0, a1, b0, b1 int) {
+	// 去掉公共前后缀，缩小比较规模
+	for a0 < a1 && b0 < b1 && d.a[a0] == d.b[b0] {
+		d.equal(a0, b0)
+		a0++
+		b0++
+	}
+	suf := 0
+	for a1-suf > a0 && b1-suf > b0 && d.a[a1-1-suf] == d.b[b1-1-suf] {
+		suf++
+	}
+	a1, b1 = a1-suf, b1-suf
+
+	switch {
+	case a0 == a1:
+		for j := b0; j < b1; j++ {
+			d.out = append(d.out, Line{Kind: Insert, Text: d.b[j], NewLine: j + 1})
+		}
+	case b0 == b1:
+		for i := a0; i < a1; i++ {
+			d.out = append(d.out, Line{Kind: Delete, Text: d.a[i], OldLine: i + 1})
+		}
+	default:
+		// 首尾都不相同时编辑距离至少为 2，中间蛇两侧的子问题都严格更小
+		x0, y0, x1, y1 := d.midSnake(a0, a1, b0, b1)
+		d.compare(a0, x0, b0, y0)
+		for i, j := x0, y0; i < x1; i, j = i+1, j+1 {
+			d.equal(i, j)
+		}
+		d.compare(x1, a1, y1, b1)
+	}
+
+	for i := 0; i < suf; i++ {
+		d.equal(a1+i, b1+i)
+	}
+}
+
+func (d *differ) equal(i, j int) {
+	d.out = append(d.out, Line{Kind: Equal, Text: d.a[i], OldLine: i + 1, NewLine: j + 1})
+}
+
+// 同时从两端搜索最短编辑路径，返回两者相遇处的蛇（起点、终点，均为绝对下标）
+func (d *differ) midSnake(a0, a1, b0, b1 int) (x0, y0, x1, y1 int) {
+	n, m := a1-a0, b1-b0
+	delta := n - m
+	odd := delta%2 != 0
+	off := len(d.vf) / 2
+	vf, vb := d.vf, d.vb
+	vf[off+1], vb[off+1] = 0, 0
+
+	for D := 0; D <= (n+m+1)/2; D++ {
+		// 正向：x、y 为相对 a0、b0 的偏移
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || (k != D && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.a[a0+x] == d.b[b0+y] {
+				x++
+				y++
+			}
+			vf[off+k] = x
+			// 反向对角线 c = delta - k
+			if c := delta - k; odd && c >= -(D-1) && c <= D-1 && x+vb[off+c] >= n {
+				return a0 + sx, b0 + sy, a0 + x, b0 + y
+			}
+		}
+		// 反向：x、y 为距 a1、b1 的偏移
+		for c := -D; c <= D; c += 2 {
+			var x int
+			if c == -D || (c != D && vb[off+c-1] < vb[off+c+1]) {
+				x = vb[off+c+1]
+			} else {
+				x = vb[off+c-1] + 1
+			}
+			y := x - c
+			sx, sy := x, y
+			for x < n && y < m && d.a[a1-1-x] == d.b[b1-1-y] {
+				x++
+				y++
+			}
+			vb[off+c] = x
+			if k := delta - c; !odd && k >= -D && k <= D && x+vf[off+k] >= n {
+				return a1 - x, b1 - y, a1 - sx, b1 - sy
+			}
+		}
+	}
+	// 不会走到这里：D 达到 ceil((n+m)/2) 之前两端必然相遇
+	panic("diff: middle snake not found")
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,96 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	a := "a\nb\nc\nd\ne\n"
+	b := "a\nb\nx\nd\ne\nf\n"
+	got := Unified(a, b, "r1", "r2", 1)
+	want := "--- r1\n+++ r2\n" +
+		"@@ -2,4 +2,5 @@\n" +
+		" b\n-c\n+x\n d\n e\n+f\n"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
+
+func TestUnifiedSeparateHunks(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	b := "0\n1\n2\n3\n4\n5\n6\n7\n8\n"
+	got := Unified(a, b, "a", "b", 1)
+	want := "--- a\n+++ b\n" +
+		"@@ -1,1 +1,2 @@\n+0\n 1\n" +
+		"@@ -8,2 +9,1 @@\n 8\n-9\n"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
+
+func TestUnifiedEqual(t *testing.T) {
+	if got := Unified("same\n", "same", "a", "b", 3); got != "" {
+		t.Fatalf("expected empty diff, got %q", got)
+	}
+}
+
+func TestLinesRoundTrip(t *testing.T) {
+	a := "the\nquick\nbrown\nfox\njumps\nover\nthe\nlazy\ndog"
+	b := "a\nquick\nfox\nleaps\nover\nthe\nsleepy\nlazy\ncat"
+	var oldOut, newOut []string
+	for _, l := range Lines(a, b) {
+		if l.Kind != Insert {
+			oldOut = append(oldOut, l.Text)
+		}
+		if l.Kind != Delete {
+			newOut = append(newOut, l.Text)
+		}
+	}
+	if got := strings.Join(oldOut, "\n"); got != a {
+		t.Fatalf("old side mismatch: %q", got)
+	}
+	if got := strings.Join(newOut, "\n"); got != b {
+		t.Fatalf("new side mismatch: %q", got)
+	}
+}
+
+// 大文本与空文本比较直接整块删除/插入
+func TestLinesAgainstEmpty(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 20000; i++ {
+		sb.WriteString("line\n")
+	}
+	got := Lines(sb.String(), "")
+	if len(got) != 20000 || got[0].Kind != Delete || got[19999].OldLine != 20000 {
+		t.Fatalf("unexpected result: %d lines", len(got))
+	}
+	got = Lines("", sb.String())
+	if len(got) != 20000 || got[0].Kind != Insert || got[19999].NewLine != 20000 {
+		t.Fatalf("unexpected result: %d lines", len(got))
+	}
+}
+
+// 两段完全不同的文本：编辑路径最长的情况
+func TestLinesDisjoint(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 3000; i++ {
+		a = append(a, "a"+strconv.Itoa(i))
+		b = append(b, "b"+strconv.Itoa(i))
+	}
+	dels, ins := 0, 0
+	for _, l := range Lines(strings.Join(a, "\n"), strings.Join(b, "\n")) {
+		switch l.Kind {
+		case Delete:
+			dels++
+		case Insert:
+			ins++
+		default:
+			t.Fatalf("unexpected equal line %q", l.Text)
+		}
+	}
+	if dels != 3000 || ins != 3000 {
+		t.Fatalf("want 3000/3000 got %d/%d", dels, ins)
+	}
+}
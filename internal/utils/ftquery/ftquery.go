@@ -0,0 +1,132 @@
+package ftquery
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+* 把用户输入整理为 InnoDB BOOLEAN MODE 一定能解析的表达式，避免语法错误
+* 保留：词、词尾 *、前缀运算符 + - ~ < >、成对的引号短语（及其后的 @N）、括号分组
+* 去掉：其它符号、不挨着词的运算符、多余的右括号、空短语与空分组；补齐未闭合的引号与括号
+* @return 整理后的表达式，没有任何可检索的词时为空串
+ */
+func Boolean(q string) string {
+	p := parser{s: []rune(q)}
+	return strings.Join(p.seq(0), " ")
+}
+
+type parser struct {
+	s   []rune
+	pos int
+}
+
+func (p *parser) peek() rune {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+// 解析一串并列的项；depth > 0 时遇到右括号返回
+func (p *parser) seq(depth int) []string {
+	var items []string
+	for p.pos < len(p.s) {
+		r := p.peek()
+		switch {
+		case r == ')':
+			p.pos++
+			if depth > 0 {
+				return items
+			}
+			continue
+		case !isOperator(r) && !isWord(r) && r != '"' && r != '(':
+			p.pos++
+			continue
+		}
+
+		// 连续的前缀运算符只保留最后一个
+		var op rune
+		for isOperator(p.peek()) {
+			op = p.peek()
+			p.pos++
+		}
+		item := p.term(depth)
+		if item == "" {
+			continue
+		}
+		if op != 0 {
+			item = string(op) + item
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func (p *parser) term(depth int) string {
+	switch r := p.peek(); {
+	case r == '"':
+		return p.phrase()
+	case r == '(':
+		p.pos++
+		sub := p.seq(depth + 1)
+		if len(sub) == 0 {
+			return ""
+		}
+		return "(" + strings.Join(sub, " ") + ")"
+	case isWord(r):
+		start := p.pos
+		for isWord(p.peek()) {
+			p.pos++
+		}
+		w := string(p.s[start:p.pos])
+		if p.peek() == '*' {
+			for p.peek() == '*' {
+				p.pos++
+			}
+			w += "*"
+		}
+		return w
+	}
+	return ""
+}
+
+// 引号短语：内部只保留词；缺少右引号时读到结尾
+func (p *parser) phrase() string {
+	p.pos++
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] != '"' {
+		p.pos++
+	}
+	body := p.s[start:p.pos]
+	if p.pos < len(p.s) {
+		p.pos++
+	}
+	words := strings.FieldsFunc(string(body), func(r rune) bool { return !isWord(r) })
+	if len(words) == 0 {
+		return ""
+	}
+	out := `"` + strings.Join(words, " ") + `"`
+
+	// 邻近搜索 "a b"@N
+	if p.peek() == '@' {
+		start := p.pos + 1
+		end := start
+		for end < len(p.s) && p.s[end] >= '0' && p.s[end] <= '9' {
+			end++
+		}
+		if end > start {
+			out += string(p.s[p.pos:end])
+			p.pos = end
+		}
+	}
+	return out
+}
+
+func isOperator(r rune) bool {
+	return r == '+' || r == '-' || r == '~' || r == '<' || r == '>'
+}
+
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
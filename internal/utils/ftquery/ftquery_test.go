@@ -0,0 +1,39 @@
+package ftquery
+
+import "testing"
+
+func TestBoolean(t *testing.T) {
+	for in, want := range map[string]string{
+		// 合法表达式原样保留
+		"+mysql -oracle":               "+mysql -oracle",
+		"go*":                          "go*",
+		`"full text" search`:           `"full text" search`,
+		`"full text"@3`:                `"full text"@3`,
+		"+apple +(>turnover <strudel)": "+apple +(>turnover <strudel)",
+		"~noise 中文":                    "~noise 中文",
+
+		// InnoDB 会报语法错误的输入
+		"a@b":        "a b",
+		`"`:          "",
+		`""`:         "",
+		`"unclosed`:  `"unclosed"`,
+		`"a@b"`:      `"a b"`,
+		`"a"@`:       `"a"`,
+		"(a":         "(a)",
+		"a)":         "a",
+		"()":         "",
+		"((a) b":     "((a) b)",
+		"+":          "",
+		"- a":        "a",
+		"+-a":        "-a",
+		"a-b":        "a -b",
+		"*a":         "a",
+		"a**":        "a*",
+		"@@ ** ~~ )": "",
+		"c++ (":      "c",
+	} {
+		if got := Boolean(in); got != want {
+			t.Errorf("Boolean(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package jwtutil
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -14,6 +16,10 @@ var (
 type Claims struct {
 	UserID uint   `json:"uid"`
 	Role   string `json:"role"`
+	// 会话（刷新令牌家族）ID，用于整体吊销；jti 见 RegisteredClaims.ID
+	SessionID string `json:"sid,omitempty"`
+	// 登录时通过了二次验证
+	MFA bool `json:"mfa,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -21,30 +27,50 @@ type Manager struct {
 	Secret []byte
 	Issuer string
 	TTL    time.Duration
+
+	// 配置 SigningKey 后改用非对称签名（RS256/EdDSA），token 头带 kid；
+	// VerifyKeys 为轮换期内仍可验签的旧公钥。此时 Secret 非空则继续接受旧的 HS256 token
+	SigningKey *Key
+	VerifyKeys []*Key
 }
 
 func (m Manager) Sign(userID uint, role string) (string, error) {
+	return m.SignSession(userID, role, "")
+}
+
+// 签发属于某个会话的 access token
+func (m Manager) SignSession(userID uint, role, sessionID string) (string, error) {
+	return m.sign(Claims{UserID: userID, Role: role, SessionID: sessionID})
+}
+
+// 同 SignSession，token 带 mfa 标记（该会话登录时通过了二次验证）
+func (m Manager) SignMFASession(userID uint, role, sessionID string) (string, error) {
+	return m.sign(Claims{UserID: userID, Role: role, SessionID: sessionID, MFA: true})
+}
+
+func (m Manager) sign(claims Claims) (string, error) {
+	jti, err := newID()
+	if err != nil {
+		return "", err
+	}
 	now := time.Now()
-	claims := Claims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    m.Issuer,
-			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(m.TTL)),
-		},
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        jti,
+		Issuer:    m.Issuer,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(m.TTL)),
+	}
+	if k := m.SigningKey; k != nil {
+		t := jwt.NewWithClaims(k.Method, claims)
+		t.Header["kid"] = k.ID
+		return t.SignedString(k.Private)
 	}
 	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return t.SignedString(m.Secret)
 }
 
 func (m Manager) Parse(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (any, error) {
-		if t.Method != jwt.SigningMethodHS256 {
-			return nil, ErrInvalidToken
-		}
-		return m.Secret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, m.keyFunc)
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
@@ -61,3 +87,50 @@ func (m Manager) Parse(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// 按 alg/kid 选择验签密钥；算法必须与密钥类型一致，防止算法混淆
+func (m Manager) keyFunc(t *jwt.Token) (any, error) {
+	if t.Method == jwt.SigningMethodHS256 {
+		if len(m.Secret) == 0 {
+			return nil, ErrInvalidToken
+		}
+		return m.Secret, nil
+	}
+	kid, _ := t.Header["kid"].(string)
+	for _, k := range m.keys() {
+		if k.ID == kid && k.Method == t.Method {
+			return k.Public, nil
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+func (m Manager) keys() []*Key {
+	out := make([]*Key, 0, len(m.VerifyKeys)+1)
+	if m.SigningKey != nil {
+		out = append(out, m.SigningKey)
+	}
+	for _, k := range m.VerifyKeys {
+		if k != nil && (m.SigningKey == nil || k.ID != m.SigningKey.ID) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// 当前可验签的公钥集合（用于 /.well-known/jwks.json）；HS256 模式下为空
+func (m Manager) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range m.keys() {
+		set.Keys = append(set.Keys, k.JWK())
+	}
+	return set
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,6 +1,16 @@
 package jwtutil
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -29,3 +39,167 @@ func TestJWTSignParse(t *testing.T) {
 		t.Fatalf("role want user got %s", claims.Role)
 	}
 }
+
+func TestJWTSession(t *testing.T) {
+	m := Manager{Secret: []byte("test-secret-very-long"), Issuer: "blog-service", TTL: time.Minute}
+
+	a, err := m.SignSession(1, "admin", "fam-1")
+	if err != nil {
+		t.Fatalf("sign err: %v", err)
+	}
+	b, _ := m.SignSession(1, "admin", "fam-1")
+
+	ca, err := m.Parse(a)
+	if err != nil {
+		t.Fatalf("parse err: %v", err)
+	}
+	cb, _ := m.Parse(b)
+	if ca.SessionID != "fam-1" {
+		t.Fatalf("sid want fam-1 got %q", ca.SessionID)
+	}
+	if ca.ID == "" || ca.ID == cb.ID {
+		t.Fatalf("jti must be unique, got %q and %q", ca.ID, cb.ID)
+	}
+	if ca.MFA {
+		t.Fatalf("plain session must not carry mfa flag")
+	}
+
+	c, _ := m.SignMFASession(1, "admin", "fam-2")
+	cc, err := m.Parse(c)
+	if err != nil {
+		t.Fatalf("parse err: %v", err)
+	}
+	if !cc.MFA || cc.SessionID != "fam-2" {
+		t.Fatalf("mfa session claims wrong: %+v", cc)
+	}
+}
+
+func TestJWTAsymmetricRotation(t *testing.T) {
+	_, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldKey := keyFromSigner(t, rsaPriv)
+	newKey := keyFromSigner(t, edPriv)
+
+	oldM := Manager{Issuer: "blog-service", TTL: time.Minute, SigningKey: oldKey}
+	oldToken, err := oldM.Sign(7, "user")
+	if err != nil {
+		t.Fatalf("sign err: %v", err)
+	}
+
+	// 轮换后：新密钥签发，旧公钥仍可验签
+	verifyOnly := *oldKey
+	verifyOnly.Private = nil
+	m := Manager{Issuer: "blog-service", TTL: time.Minute, SigningKey: newKey, VerifyKeys: []*Key{&verifyOnly}}
+	if c, err := m.Parse(oldToken); err != nil || c.UserID != 7 {
+		t.Fatalf("old token should still verify: %v", err)
+	}
+	newToken, err := m.Sign(8, "admin")
+	if err != nil {
+		t.Fatalf("sign err: %v", err)
+	}
+	if c, err := m.Parse(newToken); err != nil || c.UserID != 8 {
+		t.Fatalf("new token should verify: %v", err)
+	}
+
+	// 旧公钥移除后旧 token 失效
+	m.VerifyKeys = nil
+	if _, err := m.Parse(oldToken); err == nil {
+		t.Fatal("old token must be rejected after key removal")
+	}
+
+	// 未配置 Secret 时不接受 HS256
+	hs := Manager{Secret: []byte("test-secret-very-long"), Issuer: "blog-service", TTL: time.Minute}
+	hsToken, _ := hs.Sign(1, "admin")
+	if _, err := m.Parse(hsToken); err == nil {
+		t.Fatal("hs256 token must be rejected in asymmetric mode")
+	}
+
+	set := m.JWKS()
+	if len(set.Keys) != 1 || set.Keys[0].Kid != newKey.ID || set.Keys[0].Kty != "OKP" {
+		t.Fatalf("unexpected jwks: %+v", set)
+	}
+}
+
+func TestLoadKeyFiles(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	der, _ := x509.MarshalPKCS8PrivateKey(priv)
+	privPath := filepath.Join(dir, "private.pem")
+	os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+
+	pubDER, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	pubPath := filepath.Join(dir, "public.pem")
+	os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o644)
+
+	sk, err := LoadPrivateKeyFile(privPath)
+	if err != nil {
+		t.Fatalf("load private: %v", err)
+	}
+	pk, err := LoadPublicKeyFile(pubPath)
+	if err != nil {
+		t.Fatalf("load public: %v", err)
+	}
+	if sk.ID == "" || sk.ID != pk.ID {
+		t.Fatalf("kid mismatch: %q vs %q", sk.ID, pk.ID)
+	}
+	if sk.Method.Alg() != "RS256" {
+		t.Fatalf("alg want RS256 got %s", sk.Method.Alg())
+	}
+
+	m := Manager{Issuer: "blog-service", TTL: time.Minute, SigningKey: sk}
+	token, _ := m.Sign(1, "user")
+	v := Manager{Issuer: "blog-service", VerifyKeys: []*Key{pk}}
+	if _, err := v.Parse(token); err != nil {
+		t.Fatalf("verify with public key file: %v", err)
+	}
+}
+
+func keyFromSigner(t *testing.T, s crypto.Signer) *Key {
+	t.Helper()
+	k, err := NewKey(s.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Private = s
+	return k
+}
+
+func TestJWKPublicKey(t *testing.T) {
+	_, edPriv, _ := ed25519.GenerateKey(rand.Reader)
+	rsaPriv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	for _, pub := range []crypto.PublicKey{edPriv.Public(), &rsaPriv.PublicKey} {
+		k, err := NewKey(pub)
+		if err != nil {
+			t.Fatalf("new key: %v", err)
+		}
+		got, err := k.JWK().PublicKey()
+		if err != nil {
+			t.Fatalf("parse jwk: %v", err)
+		}
+		if !got.(interface{ Equal(crypto.PublicKey) bool }).Equal(pub) {
+			t.Fatalf("%s key round trip mismatch", k.JWK().Kty)
+		}
+	}
+
+	ecPriv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecJWK := JWK{Kty: "EC", Crv: "P-256", X: b64(ecPriv.X.FillBytes(make([]byte, 32))), Y: b64(ecPriv.Y.FillBytes(make([]byte, 32)))}
+	if got, err := ecJWK.PublicKey(); err != nil || !ecPriv.PublicKey.Equal(got) {
+		t.Fatalf("ec key round trip failed: %v", err)
+	}
+
+	// EC 点不在曲线上时拒绝
+	bad := JWK{Kty: "EC", Crv: "P-256", X: b64(make([]byte, 32)), Y: b64(make([]byte, 32))}
+	if _, err := bad.PublicKey(); err == nil {
+		t.Fatalf("invalid ec point should be rejected")
+	}
+}
@@ -2,15 +2,23 @@ package markdown
 
 import (
 	"bytes"
+	stdhtml "html"
+	"strings"
 
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
 )
 
 var md = goldmark.New(
 	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(
+		// 媒体引用改写为存储后端地址
+		parser.WithASTTransformers(util.Prioritized(mediaTransformer{}, 100)),
+	),
 	goldmark.WithRendererOptions(
 		html.WithUnsafe(), // 先允许，后面用 bluemonday 过滤
 	),
@@ -18,6 +26,8 @@ var md = goldmark.New(
 
 var policy = bluemonday.UGCPolicy()
 
+var stripPolicy = bluemonday.StrictPolicy()
+
 func RenderToSafeHTML(markdownText string) (string, error) {
 	var buf bytes.Buffer
 	if err := md.Convert([]byte(markdownText), &buf); err != nil {
@@ -26,3 +36,13 @@ func RenderToSafeHTML(markdownText string) (string, error) {
 	// XSS 清洗
 	return policy.Sanitize(buf.String()), nil
 }
+
+// 渲染为纯文本（去掉所有标签），用于搜索摘要等场景
+func ToPlainText(markdownText string) (string, error) {
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(markdownText), &buf); err != nil {
+		return "", err
+	}
+	text := stdhtml.UnescapeString(stripPolicy.Sanitize(buf.String()))
+	return strings.Join(strings.Fields(text), " "), nil
+}
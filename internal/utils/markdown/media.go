@@ -0,0 +1,67 @@
+package markdown
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	mediaScheme      = "media:"
+	localMediaPrefix = "/uploads/"
+)
+
+// 媒体 key -> 公开地址；默认对应本地存储的 /uploads
+var mediaURL = func(key string) string {
+	return localMediaPrefix + key
+}
+
+// 启动时根据存储后端设置一次（非并发安全，不要在运行中修改）
+func SetMediaURLResolver(fn func(key string) string) {
+	if fn != nil {
+		mediaURL = fn
+	}
+}
+
+// 把 media:<key> 与 /uploads/<key> 形式的图片/链接地址改写为存储后端的公开地址
+type mediaTransformer struct{}
+
+func (mediaTransformer) Transform(doc *ast.Document, _ text.Reader, _ parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch v := n.(type) {
+		case *ast.Image:
+			v.Destination = rewriteMediaURL(v.Destination)
+		case *ast.Link:
+			v.Destination = rewriteMediaURL(v.Destination)
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func rewriteMediaURL(dest []byte) []byte {
+	s := string(dest)
+	var key string
+	switch {
+	case strings.HasPrefix(s, mediaScheme):
+		key = strings.TrimPrefix(s, mediaScheme)
+	case strings.HasPrefix(s, localMediaPrefix):
+		key = strings.TrimPrefix(s, localMediaPrefix)
+	default:
+		return dest
+	}
+	// 查询串与锚点不属于 key，改写后原样拼回，避免被存储后端转义
+	var suffix string
+	if i := strings.IndexAny(key, "?#"); i >= 0 {
+		key, suffix = key[:i], key[i:]
+	}
+	key = strings.TrimLeft(key, "/")
+	if key == "" {
+		return dest
+	}
+	return []byte(mediaURL(key) + suffix)
+}
@@ -0,0 +1,66 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"blog-service/internal/storage"
+)
+
+func withResolver(t *testing.T, fn func(key string) string) {
+	prev := mediaURL
+	SetMediaURLResolver(fn)
+	t.Cleanup(func() { mediaURL = prev })
+}
+
+func TestRewriteMediaURL(t *testing.T) {
+	backends := []struct {
+		name    string
+		resolve func(key string) string
+		base    string
+	}{
+		{"local", storage.NewLocal(t.TempDir(), "").URL, "/uploads/"},
+		{"s3", (&storage.S3Storage{PublicURL: "https://cdn.example.com"}).URL, "https://cdn.example.com/"},
+	}
+	for _, b := range backends {
+		withResolver(t, b.resolve)
+		cases := map[string]string{
+			"media:2024/01/a.png":       b.base + "2024/01/a.png",
+			"media:/a.png":              b.base + "a.png",
+			"/uploads/a.png":            b.base + "a.png",
+			"media:a.png?w=200":         b.base + "a.png?w=200",
+			"/uploads/a.png?w=200&h=10": b.base + "a.png?w=200&h=10",
+			"media:doc.pdf#page=2":      b.base + "doc.pdf#page=2",
+			// 不是媒体引用的地址保持不变
+			"https://example.com/uploads/a.png": "https://example.com/uploads/a.png",
+			"//cdn.other.com/a.png":             "//cdn.other.com/a.png",
+			"/static/a.png":                     "/static/a.png",
+			"a.png":                             "a.png",
+			"#section":                          "#section",
+			"media:":                            "media:",
+			"/uploads/?x=1":                     "/uploads/?x=1",
+		}
+		for in, want := range cases {
+			if got := string(rewriteMediaURL([]byte(in))); got != want {
+				t.Errorf("%s: rewrite(%q) = %q, want %q", b.name, in, got, want)
+			}
+		}
+	}
+}
+
+func TestRenderRewritesMedia(t *testing.T) {
+	withResolver(t, (&storage.S3Storage{PublicURL: "https://cdn.example.com"}).URL)
+	out, err := RenderToSafeHTML("![x](media:a.png?w=1) [doc](/uploads/b.pdf) [ext](https://example.com/c.png)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`src="https://cdn.example.com/a.png?w=1"`,
+		`href="https://cdn.example.com/b.pdf"`,
+		`href="https://example.com/c.png"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in %s", want, out)
+		}
+	}
+}
@@ -13,18 +13,20 @@ var (
 )
 
 func FromTitle(title string) string {
+	if s := Normalize(title); s != "" {
+		return s
+	}
+	return "post"
+}
+
+// 只保留小写字母、数字与连字符；没有可用字符（如纯中文）时返回空串
+func Normalize(title string) string {
 	s := strings.ToLower(strings.TrimSpace(title))
 	s = reNonAlnum.ReplaceAllString(s, "-")
 	s = reTrimDash.ReplaceAllString(s, "")
-	if s == "" {
-		s = "post"
-	}
 	if len(s) > 200 {
 		s = s[:200]
 		s = reTrimDash.ReplaceAllString(s, "")
-		if s == "" {
-			s = "post"
-		}
 	}
 	return s
 }
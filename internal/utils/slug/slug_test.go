@@ -0,0 +1,31 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"Hello, World!":  "hello-world",
+		"  Go 1.24  ":    "go-1-24",
+		"Go 语言":          "go",
+		"技术随笔":           "",
+		"---":            "",
+		"":               "",
+		"already-a-slug": "already-a-slug",
+	} {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := Normalize(strings.Repeat("a", 199) + "-b"); got != strings.Repeat("a", 199) {
+		t.Errorf("truncated slug should not end with dash: %q", got)
+	}
+}
+
+func TestFromTitleFallback(t *testing.T) {
+	if got := FromTitle("技术随笔"); got != "post" {
+		t.Errorf("got %q", got)
+	}
+}
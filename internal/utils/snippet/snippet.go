@@ -0,0 +1,92 @@
+package snippet
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// 从文本中截取包含关键词的片段，并用 <mark> 高亮；输出已做 HTML 转义
+// width 为片段的大致字符数（按 rune 计）
+func Highlight(text string, terms []string, width int) string {
+	runes := []rune(text)
+	lower := toLowerRunes(runes)
+
+	needles := make([][]rune, 0, len(terms))
+	for _, t := range terms {
+		if t = strings.TrimSpace(t); t != "" {
+			needles = append(needles, toLowerRunes([]rune(t)))
+		}
+	}
+
+	// 以第一个命中位置为中心截取
+	start, end := 0, len(runes)
+	if width > 0 && len(runes) > width {
+		center := 0
+		if pos, _ := indexAny(lower, needles, 0); pos >= 0 {
+			center = pos
+		}
+		start = max(center-width/3, 0)
+		end = min(start+width, len(runes))
+		start = max(end-width, 0)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	i := start
+	for i < end {
+		pos, n := indexAny(lower[:end], needles, i)
+		if pos < 0 {
+			b.WriteString(html.EscapeString(string(runes[i:end])))
+			break
+		}
+		b.WriteString(html.EscapeString(string(runes[i:pos])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[pos : pos+n])))
+		b.WriteString("</mark>")
+		i = pos + n
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// 返回 from 之后最早出现的关键词位置及长度；同一位置优先最长的关键词
+func indexAny(hay []rune, needles [][]rune, from int) (int, int) {
+	for i := from; i < len(hay); i++ {
+		best := 0
+		for _, n := range needles {
+			if len(n) > best && hasPrefix(hay[i:], n) {
+				best = len(n)
+			}
+		}
+		if best > 0 {
+			return i, best
+		}
+	}
+	return -1, 0
+}
+
+func hasPrefix(s, prefix []rune) bool {
+	if len(prefix) > len(s) {
+		return false
+	}
+	for i := range prefix {
+		if s[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 逐 rune 转小写，保证与原文下标一一对应
+func toLowerRunes(rs []rune) []rune {
+	out := make([]rune, len(rs))
+	for i, r := range rs {
+		out[i] = unicode.ToLower(r)
+	}
+	return out
+}
@@ -0,0 +1,20 @@
+package snippet
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	got := Highlight("Hello Go <world>, go!", []string{"go"}, 0)
+	want := "Hello <mark>Go</mark> &lt;world&gt;, <mark>go</mark>!"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
+
+func TestHighlightWindow(t *testing.T) {
+	text := "aaaaaaaaaa bbbbbbbbbb keyword cccccccccc dddddddddd"
+	got := Highlight(text, []string{"keyword"}, 20)
+	want := "…bbbbb <mark>keyword</mark> cccccc…"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
@@ -0,0 +1,37 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// 生成随机不透明 token，返回明文（发给用户）与哈希（入库）
+func Generate(nbytes int) (plain string, hash string, err error) {
+	b := make([]byte, nbytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	plain = base64.RawURLEncoding.EncodeToString(b)
+	return plain, Hash(plain), nil
+}
+
+// 入库只存 SHA-256，泄库也无法直接使用
+func Hash(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// HMAC-SHA256 签名（base64url），用于邮件中的自校验链接，无需入库
+func Sign(secret []byte, msg string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(msg))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// 常量时间比较签名
+func VerifySignature(secret []byte, msg, sig string) bool {
+	return hmac.Equal([]byte(Sign(secret, msg)), []byte(sig))
+}
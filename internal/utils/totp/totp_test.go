@@ -0,0 +1,70 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量（取 8 位结果的低 6 位）
+func TestCodeRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, c := range cases {
+		got, err := Code(secret, Step(time.Unix(c.unix, 0)))
+		if err != nil {
+			t.Fatalf("code err: %v", err)
+		}
+		if got != c.want {
+			t.Fatalf("t=%d want %s got %s", c.unix, c.want, got)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("secret err: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	prev, _ := Code(secret, Step(now)-1)
+
+	step, ok := Validate(secret, prev, now, 1)
+	if !ok || step != Step(now)-1 {
+		t.Fatalf("previous step should match within skew, ok=%v step=%d", ok, step)
+	}
+	if _, ok := Validate(secret, prev, now, 0); ok {
+		t.Fatalf("previous step should not match without skew")
+	}
+	if _, ok := Validate(secret, "12345", now, 1); ok {
+		t.Fatalf("short code should not match")
+	}
+	// 手动输入的小写/空格密钥同样可用
+	cur, _ := Code(secret, Step(now))
+	if _, ok := Validate(strings.ToLower(secret[:4]+" "+secret[4:]), cur, now, 0); !ok {
+		t.Fatalf("normalized secret should match")
+	}
+}
+
+func TestURI(t *testing.T) {
+	u := URI("Blog Service", "alice@example.com", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(u, "otpauth://totp/Blog%20Service:alice@example.com?") {
+		t.Fatalf("unexpected label: %s", u)
+	}
+	for _, p := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=Blog+Service", "digits=6", "period=30"} {
+		if !strings.Contains(u, p) {
+			t.Fatalf("uri %s missing %s", u, p)
+		}
+	}
+}
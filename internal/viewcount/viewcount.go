@@ -0,0 +1,171 @@
+package viewcount
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 写回数据库：posts.id -> 本批新增浏览量
+type FlushFunc func(counts map[uint]uint64) error
+
+const (
+	defaultInterval = 10 * time.Second
+	defaultWindow   = 30 * time.Minute
+	// 去重记录上限，超过后新访客照常计数但不再记录（防止内存被刷爆）
+	defaultMaxSeen = 200000
+)
+
+/*
+* 浏览量计数器：请求只在内存中累加，后台定期批量写回，
+* 避免热门文章每次访问都 UPDATE 同一行造成行锁竞争。
+* 同一访客在去重窗口内重复访问同一篇文章只算一次；多实例部署时各实例独立去重。
+ */
+type Counter struct {
+	Flush    FlushFunc
+	Interval time.Duration // 写回间隔
+	Window   time.Duration // 去重窗口
+	MaxSeen  int
+
+	mu      sync.Mutex
+	pending map[uint]uint64
+	seen    map[string]time.Time // 访客+文章 -> 过期时间
+	flushMu sync.Mutex           // 保证同一时刻只有一次写回
+
+	now func() time.Time
+}
+
+func New(flush FlushFunc, interval, window time.Duration) *Counter {
+	return &Counter{Flush: flush, Interval: interval, Window: window, now: time.Now}
+}
+
+/*
+* 记录一次浏览
+* @param visitor 访客标识（用户 id 或 IP+UA），为空时不去重
+* @return 是否计入（窗口内重复访问返回 false）
+ */
+func (c *Counter) Hit(postID uint, visitor string) bool {
+	now := c.clock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if visitor != "" {
+		key := visitor + "|" + strconv.FormatUint(uint64(postID), 10)
+		if exp, ok := c.seen[key]; ok && now.Before(exp) {
+			return false
+		}
+		if c.seen == nil {
+			c.seen = make(map[string]time.Time)
+		}
+		if len(c.seen) < c.maxSeen() {
+			c.seen[key] = now.Add(c.window())
+		}
+	}
+	if c.pending == nil {
+		c.pending = make(map[uint]uint64)
+	}
+	c.pending[postID]++
+	return true
+}
+
+// 后台定期写回，ctx 结束时返回；退出前的最后一次写回由调用方在 HTTP 服务停止后执行 FlushNow
+func (c *Counter) Run(ctx context.Context) {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("view counter started (flush interval %s)", interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("view counter stopped")
+			return
+		case <-ticker.C:
+			if err := c.FlushNow(); err != nil {
+				log.Printf("flush view counts failed: %v", err)
+			}
+		}
+	}
+}
+
+// 立即写回累计的浏览量并清理过期的去重记录；写回失败时计数保留到下一次
+func (c *Counter) FlushNow() error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	now := c.clock()
+	c.mu.Lock()
+	batch := c.pending
+	c.pending = nil
+	for k, exp := range c.seen {
+		if !now.Before(exp) {
+			delete(c.seen, k)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := c.Flush(batch); err != nil {
+		c.mu.Lock()
+		if c.pending == nil {
+			c.pending = make(map[uint]uint64, len(batch))
+		}
+		for id, n := range batch {
+			c.pending[id] += n
+		}
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (c *Counter) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *Counter) window() time.Duration {
+	if c.Window > 0 {
+		return c.Window
+	}
+	return defaultWindow
+}
+
+func (c *Counter) maxSeen() int {
+	if c.MaxSeen > 0 {
+		return c.MaxSeen
+	}
+	return defaultMaxSeen
+}
+
+// 常见爬虫、预览抓取与命令行工具的 UA 关键字（小写）
+var botMarkers = []string{
+	"bot", "crawl", "spider", "slurp", "archiver", "fetcher",
+	"facebookexternalhit", "embedly", "preview", "headless", "lighthouse",
+	"curl", "wget", "python-requests", "python-urllib", "go-http-client",
+	"java/", "okhttp", "axios", "node-fetch", "libwww-perl", "httpclient",
+}
+
+// UA 为空或命中已知爬虫关键字时视为机器访问，不计浏览量
+func IsBot(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+	for _, m := range botMarkers {
+		if strings.Contains(ua, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package viewcount
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHitDedupAndFlush(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var got map[uint]uint64
+	c := New(func(m map[uint]uint64) error { got = m; return nil }, time.Second, time.Minute)
+	c.now = func() time.Time { return now }
+
+	if !c.Hit(1, "ip:a") {
+		t.Fatal("first view should count")
+	}
+	if c.Hit(1, "ip:a") {
+		t.Fatal("repeat view within window should not count")
+	}
+	c.Hit(1, "ip:b")
+	c.Hit(2, "ip:a")
+	// 无访客标识时不去重
+	c.Hit(3, "")
+	c.Hit(3, "")
+
+	if err := c.FlushNow(); err != nil {
+		t.Fatal(err)
+	}
+	if got[1] != 2 || got[2] != 1 || got[3] != 2 || len(got) != 3 {
+		t.Fatalf("unexpected batch %v", got)
+	}
+
+	// 没有新增时不写回
+	got = nil
+	if err := c.FlushNow(); err != nil || got != nil {
+		t.Fatalf("empty flush: %v %v", err, got)
+	}
+
+	now = now.Add(time.Minute)
+	if !c.Hit(1, "ip:a") {
+		t.Fatal("view after window should count again")
+	}
+}
+
+func TestFlushFailureKeepsCounts(t *testing.T) {
+	fail := true
+	var got map[uint]uint64
+	c := New(func(m map[uint]uint64) error {
+		if fail {
+			return errors.New("db down")
+		}
+		got = m
+		return nil
+	}, 0, 0)
+
+	c.Hit(1, "")
+	if err := c.FlushNow(); err == nil {
+		t.Fatal("want error")
+	}
+	c.Hit(1, "")
+	fail = false
+	if err := c.FlushNow(); err != nil {
+		t.Fatal(err)
+	}
+	if got[1] != 2 {
+		t.Fatalf("want 2 got %d", got[1])
+	}
+}
+
+func TestMaxSeen(t *testing.T) {
+	c := New(func(map[uint]uint64) error { return nil }, 0, 0)
+	c.MaxSeen = 1
+	c.Hit(1, "a")
+	c.Hit(1, "b")
+	// 超过上限的访客没有被记录，重复访问仍会计数
+	if !c.Hit(1, "b") {
+		t.Fatal("untracked visitor should count")
+	}
+	if c.Hit(1, "a") {
+		t.Fatal("tracked visitor should be deduplicated")
+	}
+}
+
+func TestIsBot(t *testing.T) {
+	for ua, want := range map[string]bool{
+		"": true,
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)": true,
+		"Mozilla/5.0 (compatible; bingbot/2.0)":                                    true,
+		"facebookexternalhit/1.1":                                                  true,
+		"curl/8.4.0":                                                               true,
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0 Safari/537.36": true,
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15":  false,
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148 MicroMessenger/8.0":        false,
+	} {
+		if got := IsBot(ua); got != want {
+			t.Errorf("IsBot(%q) = %v, want %v", ua, got, want)
+		}
+	}
+}
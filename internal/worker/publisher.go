@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"blog-service/internal/services"
+)
+
+// 定时发布：周期性把到期的 scheduled 文章转为 published
+// 多实例同时运行是安全的（见 PostRepo.PublishDue）；经 PostService 发布，与手动发布一样清空缓存
+type ScheduledPublisher struct {
+	Posts     *services.PostService
+	Interval  time.Duration
+	BatchSize int
+}
+
+func (p *ScheduledPublisher) Run(ctx context.Context) {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("scheduled publisher started (interval %s)", interval)
+	for {
+		p.RunOnce()
+		select {
+		case <-ctx.Done():
+			log.Println("scheduled publisher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 一次扫描：一批处理不完时继续下一批
+func (p *ScheduledPublisher) RunOnce() {
+	batch := p.BatchSize
+	if batch <= 0 {
+		batch = 100
+	}
+	for {
+		ids, err := p.Posts.PublishDue(time.Now(), batch)
+		if err != nil {
+			log.Printf("publish scheduled posts failed: %v", err)
+			return
+		}
+		if len(ids) > 0 {
+			log.Printf("published %d scheduled post(s): %v", len(ids), ids)
+		}
+		if len(ids) < batch {
+			return
+		}
+	}
+}
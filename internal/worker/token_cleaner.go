@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"blog-service/internal/repositories"
+)
+
+// 定期清理过期的 refresh token、吊销记录、登录挑战与登录失败记录，避免表无限增长
+type TokenCleaner struct {
+	Tokens        *repositories.RefreshTokenRepo
+	MFA           *repositories.MFARepo          // 为 nil 时跳过
+	LoginFailures *repositories.LoginFailureRepo // 为 nil 时跳过
+	Interval      time.Duration
+}
+
+func (w *TokenCleaner) Run(ctx context.Context) {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := w.Tokens.PurgeExpired(time.Now()); err != nil {
+			log.Printf("purge expired tokens failed: %v", err)
+		} else if n > 0 {
+			log.Printf("purged %d expired token record(s)", n)
+		}
+		if w.MFA != nil {
+			if _, err := w.MFA.PurgeExpiredChallenges(time.Now()); err != nil {
+				log.Printf("purge expired mfa challenges failed: %v", err)
+			}
+		}
+		if w.LoginFailures != nil {
+			// 锁定最长 1 小时，一天没有新失败的记录已无意义
+			if _, err := w.LoginFailures.PurgeStale(time.Now().Add(-24 * time.Hour)); err != nil {
+				log.Printf("purge stale login failures failed: %v", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}